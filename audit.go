@@ -0,0 +1,91 @@
+package heliospectra
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+)
+
+// IPRange is an inclusive range of IPv4 addresses.
+type IPRange struct {
+	Start net.IP
+	End   net.IP
+}
+
+// IsZero reports whether the range is unset.
+func (r IPRange) IsZero() bool {
+	return r.Start == nil && r.End == nil
+}
+
+// Contains reports whether ip falls within the range.
+func (r IPRange) Contains(ip net.IP) bool {
+	ip4, start, end := ip.To4(), r.Start.To4(), r.End.To4()
+	if ip4 == nil || start == nil || end == nil {
+		return false
+	}
+	return bytes.Compare(ip4, start) >= 0 && bytes.Compare(ip4, end) <= 0
+}
+
+// AddressingIssueKind is the kind of problem found by AuditAddressing.
+type AddressingIssueKind int
+
+const (
+	// AddressingDHCPOutsidePool is a DHCP fixture whose address is outside the
+	// DHCP pool, in space reserved for static addresses.
+	AddressingDHCPOutsidePool AddressingIssueKind = iota
+	// AddressingStaticInPool is a statically addressed fixture whose address
+	// is inside the DHCP pool, where it may collide with a lease.
+	AddressingStaticInPool
+	// AddressingDuplicateIP is a fixture sharing its address with another.
+	AddressingDuplicateIP
+)
+
+// AddressingIssue is a problem with a fixture's network addressing.
+type AddressingIssue struct {
+	Device  DeviceInfo
+	Kind    AddressingIssueKind
+	Message string
+}
+
+// AuditAddressing checks the addressing of devices found during a scan. If
+// dhcpPool is set, it flags DHCP fixtures with addresses outside the pool and
+// static fixtures with addresses inside it. It always flags fixtures that
+// share an address. Fixtures without an address, such as DHCP fixtures that
+// haven't been given a lease yet, are skipped.
+func AuditAddressing(devices []DeviceInfo, dhcpPool IPRange) []AddressingIssue {
+	var issues []AddressingIssue
+	seen := make(map[string]DeviceInfo)
+	for _, di := range devices {
+		if di.IPAddr == nil {
+			continue
+		}
+		if !dhcpPool.IsZero() {
+			inPool := dhcpPool.Contains(di.IPAddr)
+			if di.DHCP && !inPool {
+				issues = append(issues, AddressingIssue{
+					Device:  di,
+					Kind:    AddressingDHCPOutsidePool,
+					Message: fmt.Sprintf("DHCP fixture %s has address %s outside the DHCP pool", di.MAC, di.IPAddr),
+				})
+			} else if !di.DHCP && inPool {
+				issues = append(issues, AddressingIssue{
+					Device:  di,
+					Kind:    AddressingStaticInPool,
+					Message: fmt.Sprintf("static fixture %s has address %s inside the DHCP pool", di.MAC, di.IPAddr),
+				})
+			}
+		}
+
+		key := di.IPAddr.String()
+		if other, ok := seen[key]; ok {
+			issues = append(issues, AddressingIssue{
+				Device:  di,
+				Kind:    AddressingDuplicateIP,
+				Message: fmt.Sprintf("fixture %s has the same address %s as fixture %s", di.MAC, di.IPAddr, other.MAC),
+			})
+			continue
+		}
+		seen[key] = di
+	}
+	return issues
+}
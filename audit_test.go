@@ -0,0 +1,65 @@
+package heliospectra
+
+import (
+	"net"
+	"testing"
+)
+
+func TestIPRange_Contains(t *testing.T) {
+	r := IPRange{Start: net.IPv4(192, 168, 1, 100), End: net.IPv4(192, 168, 1, 200)}
+	tests := []struct {
+		ip       net.IP
+		expected bool
+	}{
+		{ip: net.IPv4(192, 168, 1, 100), expected: true},
+		{ip: net.IPv4(192, 168, 1, 150), expected: true},
+		{ip: net.IPv4(192, 168, 1, 200), expected: true},
+		{ip: net.IPv4(192, 168, 1, 99), expected: false},
+		{ip: net.IPv4(192, 168, 2, 150), expected: false},
+		{ip: nil, expected: false},
+	}
+	for _, tt := range tests {
+		if got := r.Contains(tt.ip); got != tt.expected {
+			t.Errorf("Contains(%s): expected %t, got %t", tt.ip, tt.expected, got)
+		}
+	}
+}
+
+func TestAuditAddressing(t *testing.T) {
+	pool := IPRange{Start: net.IPv4(192, 168, 1, 100), End: net.IPv4(192, 168, 1, 200)}
+	devices := []DeviceInfo{
+		{MAC: "64:1A:10:10:10:01", DHCP: true, IPAddr: net.IPv4(192, 168, 1, 120)},
+		{MAC: "64:1A:10:10:10:02", DHCP: true, IPAddr: net.IPv4(192, 168, 1, 20)},
+		{MAC: "64:1A:10:10:10:03", DHCP: false, IPAddr: net.IPv4(192, 168, 1, 30)},
+		{MAC: "64:1A:10:10:10:04", DHCP: false, IPAddr: net.IPv4(192, 168, 1, 150)},
+		{MAC: "64:1A:10:10:10:05", DHCP: false, IPAddr: net.IPv4(192, 168, 1, 30)},
+		// a DHCP fixture without a lease yet
+		{MAC: "64:1A:10:10:10:06", DHCP: true},
+	}
+
+	issues := AuditAddressing(devices, pool)
+	expected := []struct {
+		mac  string
+		kind AddressingIssueKind
+	}{
+		{mac: "64:1A:10:10:10:02", kind: AddressingDHCPOutsidePool},
+		{mac: "64:1A:10:10:10:04", kind: AddressingStaticInPool},
+		{mac: "64:1A:10:10:10:05", kind: AddressingDuplicateIP},
+	}
+	if len(issues) != len(expected) {
+		t.Fatalf("expected %d issues, got %d: %+v", len(expected), len(issues), issues)
+	}
+	for i, exp := range expected {
+		if issues[i].Device.MAC != exp.mac || issues[i].Kind != exp.kind {
+			t.Errorf("issue %d: expected kind %d for %s, got kind %d for %s", i, exp.kind, exp.mac, issues[i].Kind, issues[i].Device.MAC)
+		}
+		if issues[i].Message == "" {
+			t.Errorf("issue %d: expected a message", i)
+		}
+	}
+
+	issues = AuditAddressing(devices, IPRange{})
+	if len(issues) != 1 || issues[0].Kind != AddressingDuplicateIP {
+		t.Errorf("expected only the duplicate IP issue without a pool, got %+v", issues)
+	}
+}
@@ -0,0 +1,111 @@
+package heliospectra
+
+import (
+	"context"
+	"fmt"
+)
+
+// ChannelState is the current state of a single wavelength channel on a Device.
+type ChannelState struct {
+	// Label is the channel's wavelength as reported by the Device, such as
+	// "660nm" or "5700K".
+	Label string
+	// Nanometers is the channel's wavelength in nanometers, or 0 if the channel
+	// isn't described by a wavelength (such as a white channel described by
+	// its color temperature).
+	Nanometers int
+	// Intensity is the channel's current intensity, from 0 to MaxIntensity.
+	Intensity int
+	// IntensityPct is Intensity as a percentage of MaxIntensity.
+	IntensityPct float64
+}
+
+// ChannelStates returns the label and current intensity of each channel on this
+// Device. It fetches a diagnostic for the channel labels and a status for the
+// live intensities.
+func (d *Device) ChannelStates(ctx context.Context) ([]ChannelState, error) {
+	diag, err := d.Diagnostic(ctx)
+	if err != nil {
+		return nil, err
+	}
+	status, err := d.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return channelStates(diag, status)
+}
+
+func channelStates(diag *Diagnostic, status *Status) ([]ChannelState, error) {
+	intensities, err := status.ParsedIntensities()
+	if err != nil {
+		return nil, err
+	}
+	if len(intensities) != diag.ChannelCount() {
+		return nil, fmt.Errorf("status has %d intensities but diagnostic has %d wavelengths", len(intensities), diag.ChannelCount())
+	}
+
+	states := make([]ChannelState, len(diag.Wavelengths))
+	for i, wl := range diag.Wavelengths {
+		nm, _ := wl.WavelengthNanometers()
+		states[i] = ChannelState{
+			Label:        wl.Wavelength,
+			Nanometers:   nm,
+			Intensity:    intensities[i],
+			IntensityPct: IntensityToPercent(intensities[i]),
+		}
+	}
+	return states, nil
+}
+
+// ReadChannel returns the current intensity of a single channel on this Device,
+// read from its status.
+func (d *Device) ReadChannel(ctx context.Context, channel int) (int, error) {
+	intensities, err := d.GetIntensities(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if channel < 0 || channel >= len(intensities) {
+		return 0, fmt.Errorf("channel %d out of range [0,%d)", channel, len(intensities))
+	}
+	return intensities[channel], nil
+}
+
+// SetIntensity sets the intensity of a single channel on this Device, leaving
+// the others as they are. The fixture only accepts every channel at once, so
+// the current intensities are read from its status first and sent back with
+// channel replaced. Another client changing the fixture between the read and
+// the write will have its change to the other channels overwritten.
+func (d *Device) SetIntensity(ctx context.Context, channel, value int) error {
+	if value < 0 || value > MaxIntensity {
+		return fmt.Errorf("intensity %d at channel %d out of range [0,%d]", value, channel, MaxIntensity)
+	}
+	intensities, err := d.GetIntensities(ctx)
+	if err != nil {
+		return err
+	}
+	if channel < 0 || channel >= len(intensities) {
+		return fmt.Errorf("channel %d out of range [0,%d)", channel, len(intensities))
+	}
+	intensities[channel] = value
+	return d.SetIntensities(ctx, intensities...)
+}
+
+// AllOff sets every channel on this Device to zero, or to its floor if the
+// Device has MinIntensities. The number of channels is read from the Device's
+// status the first time and reused by later calls.
+func (d *Device) AllOff(ctx context.Context) error {
+	d.mu.Lock()
+	channels := d.channels
+	d.mu.Unlock()
+	if channels == 0 {
+		intensities, err := d.GetIntensities(ctx)
+		if err != nil {
+			return err
+		}
+		channels = len(intensities)
+		d.mu.Lock()
+		d.channels = channels
+		d.mu.Unlock()
+	}
+	return d.SetIntensities(ctx, make([]int, channels)...)
+}
@@ -0,0 +1,169 @@
+package heliospectra
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDevice_ChannelStates(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	status := strings.Replace(statusResponse, "<j>0:0,1:0,2:0,3:0,</j>", "<j>0:100,1:500,2:0,3:1000,</j>", 1)
+	device, server := newTestDevice(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/diag.xml":
+			w.Write([]byte(diagResponse))
+		case "/status.xml":
+			w.Write([]byte(status))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			w.WriteHeader(404)
+		}
+	})
+	defer server.Close()
+
+	states, err := device.ChannelStates(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []ChannelState{
+		{Label: "450nm", Nanometers: 450, Intensity: 100, IntensityPct: 10},
+		{Label: "660nm", Nanometers: 660, Intensity: 500, IntensityPct: 50},
+		{Label: "735nm", Nanometers: 735, Intensity: 0, IntensityPct: 0},
+		{Label: "5700K", Nanometers: 0, Intensity: 1000, IntensityPct: 100},
+	}
+	if !reflect.DeepEqual(expected, states) {
+		t.Errorf("expected states=%+v\n\tgot %+v", expected, states)
+	}
+}
+
+func TestDevice_ReadChannel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	status := strings.Replace(statusResponse, "<j>0:0,1:0,2:0,3:0,</j>", "<j>0:100,1:200,2:350,3:0,</j>", 1)
+	device, server := newTestDevice(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/status.xml" {
+			t.Errorf("expected URL /status.xml, got %s", r.URL.Path)
+		}
+		w.Write([]byte(status))
+	})
+	defer server.Close()
+
+	value, err := device.ReadChannel(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != 350 {
+		t.Errorf("expected channel 2 to be 350, got %d", value)
+	}
+
+	for _, channel := range []int{-1, 4} {
+		if _, err := device.ReadChannel(ctx, channel); err == nil {
+			t.Errorf("expected an error for channel %d, got none", channel)
+		}
+	}
+}
+
+func TestDevice_SetIntensity(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	status := strings.Replace(statusResponse, "<j>0:0,1:0,2:0,3:0,</j>", "<j>0:100,1:200,2:350,3:0,</j>", 1)
+	var requests []string
+	device, server := newTestDevice(t, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path+"?"+r.URL.RawQuery)
+		if r.URL.Path == "/status.xml" {
+			w.Write([]byte(status))
+		}
+	})
+	defer server.Close()
+
+	if err := device.SetIntensity(ctx, 2, 500); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"/status.xml?", "/intensity.cgi?int=100%3A200%3A500%3A0"}
+	if !reflect.DeepEqual(expected, requests) {
+		t.Errorf("expected requests %q, got %q", expected, requests)
+	}
+
+	tests := []struct{ channel, value int }{
+		{channel: -1, value: 500},
+		{channel: 4, value: 500},
+		{channel: 0, value: -1},
+		{channel: 0, value: 1001},
+	}
+	for _, tt := range tests {
+		requests = nil
+		if err := device.SetIntensity(ctx, tt.channel, tt.value); err == nil {
+			t.Errorf("channel %d value %d: expected an error, got none", tt.channel, tt.value)
+		}
+		for _, req := range requests {
+			if strings.HasPrefix(req, "/intensity.cgi") {
+				t.Errorf("channel %d value %d: expected no intensity request, got %s", tt.channel, tt.value, req)
+			}
+		}
+	}
+}
+
+func TestDevice_AllOff(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	status := strings.Replace(statusResponse, "<j>0:0,1:0,2:0,3:0,</j>", "<j>0:100,1:200,2:350,3:0,</j>", 1)
+	var statusRequests, intensityRequests int
+	device, server := newTestDevice(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/status.xml":
+			statusRequests++
+			w.Write([]byte(status))
+		case "/intensity.cgi":
+			intensityRequests++
+			if got := r.URL.Query().Get("int"); got != "0:0:0:0" {
+				t.Errorf("expected intensities 0:0:0:0, got %s", got)
+			}
+		default:
+			t.Errorf("unexpected request for %s", r.URL.Path)
+		}
+	})
+	defer server.Close()
+
+	for i := 0; i < 2; i++ {
+		if err := device.AllOff(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if statusRequests != 1 {
+		t.Errorf("expected the channel count to be fetched once, got %d status requests", statusRequests)
+	}
+	if intensityRequests != 2 {
+		t.Errorf("expected 2 intensity requests, got %d", intensityRequests)
+	}
+
+	// channels with a floor are only lowered to it
+	var gotQuery string
+	floored, server := newTestDevice(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/status.xml":
+			w.Write([]byte(status))
+		case "/intensity.cgi":
+			gotQuery = r.URL.Query().Get("int")
+		}
+	})
+	defer server.Close()
+	floored.Logger = log.New(io.Discard, "", 0)
+	floored.MinIntensities = []int{0, 50}
+	if err := floored.AllOff(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "0:50:0:0"; gotQuery != expected {
+		t.Errorf("expected int=%s, got %s", expected, gotQuery)
+	}
+}
@@ -36,7 +36,11 @@ func demoDevice(ctx context.Context, di heliospectra.DeviceInfo) {
 	if err != nil {
 		panic(err)
 	}
-	fmt.Printf("diagnostic data: %+v\n", diag)
+	report, err := device.DescribeDiagnostic(diagCtx, diag)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Print(report)
 
 	intensities := make([]int, len(diag.Wavelengths))
 
@@ -57,22 +61,22 @@ const (
 
 func lightshow(ctx context.Context, device *heliospectra.Device, intensities []int, idx int) {
 	// turn all off
-	device.SetIntensities(ctx, intensities...)
+	device.SetIntensitiesBestEffort(ctx, intensities...)
 
 	for i := 1; i < lightshowSteps; i++ {
 		time.Sleep(30 * time.Millisecond)
 		intensities[idx] = lightshowStepSize * i
-		device.SetIntensities(ctx, intensities...)
+		device.SetIntensitiesBestEffort(ctx, intensities...)
 	}
 
 	for i := lightshowSteps; i >= 0; i-- {
 		time.Sleep(30 * time.Millisecond)
 		intensities[idx] = lightshowStepSize * i
-		device.SetIntensities(ctx, intensities...)
+		device.SetIntensitiesBestEffort(ctx, intensities...)
 	}
 
 	for i := range intensities {
 		intensities[i] = 0
 	}
-	device.SetIntensities(ctx, intensities...)
+	device.SetIntensitiesBestEffort(ctx, intensities...)
 }
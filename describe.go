@@ -0,0 +1,87 @@
+package heliospectra
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Describe returns a multi-line, human-readable report on the Device, including
+// its name, model, firmware, the current intensity of each channel,
+// temperatures, uptime, schedule state and network configuration. It fetches
+// both a diagnostic and a status.
+func (d *Device) Describe(ctx context.Context) (string, error) {
+	diag, err := d.Diagnostic(ctx)
+	if err != nil {
+		return "", err
+	}
+	return d.DescribeDiagnostic(ctx, diag)
+}
+
+// DescribeDiagnostic is like Describe, but uses diag, a Diagnostic already
+// fetched from the Device, rather than fetching another. Only the status is
+// fetched.
+func (d *Device) DescribeDiagnostic(ctx context.Context, diag *Diagnostic) (string, error) {
+	status, err := d.Status(ctx)
+	if err != nil {
+		return "", err
+	}
+	return describe(diag, status)
+}
+
+func describe(diag *Diagnostic, status *Status) (string, error) {
+	states, err := channelStates(diag, status)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Name:      %s\n", diag.DisplayName())
+	fmt.Fprintf(&buf, "Model:     %s\n", diag.Model)
+	fmt.Fprintf(&buf, "Firmware:  CPU %s, driver %s\n", diag.CPUFW, diag.DriverFW)
+	fmt.Fprintf(&buf, "Channels:\n")
+	for i, state := range states {
+		fmt.Fprintf(&buf, "  %d: %-8s %5.1f%% (%d)\n", i, state.Label, state.IntensityPct, state.Intensity)
+	}
+	fmt.Fprintf(&buf, "Temps:     %s\n", describeTemps(status))
+	fmt.Fprintf(&buf, "Uptime:    %s\n", describeUptime(status))
+	fmt.Fprintf(&buf, "Schedule:  %s\n", status.OnSchedule)
+	fmt.Fprintf(&buf, "Role:      %s\n", describeRole(diag))
+	fmt.Fprintf(&buf, "Network:   %s, IP %s, subnet %s, gateway %s\n", diag.NetworkType, diag.NetworkIP, diag.NetworkSubnet, diag.NetworkGateway)
+	return buf.String(), nil
+}
+
+// The describe helpers below fall back to the raw field when it can't be
+// parsed, so that one unusual field doesn't prevent the rest of the report.
+
+func describeTemps(status *Status) string {
+	temps, err := status.ParsedTemps()
+	if err != nil {
+		return status.Temp
+	}
+	parts := make([]string, len(temps))
+	for i, temp := range temps {
+		parts[i] = temp.String()
+	}
+	return strings.Join(parts, ", ")
+}
+
+func describeUptime(status *Status) string {
+	uptime, err := status.ParsedUptime()
+	if err != nil {
+		return status.Uptime
+	}
+	return uptime.String()
+}
+
+func describeRole(diag *Diagnostic) string {
+	role, master, err := diag.Role()
+	if err != nil {
+		return diag.MasterOrSlave
+	}
+	if role == RoleSlave {
+		return fmt.Sprintf("%s of master %s", role, master)
+	}
+	return role.String()
+}
@@ -0,0 +1,109 @@
+package heliospectra
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDevice_Describe(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	device, server := newTestDevice(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/diag.xml":
+			w.Write([]byte(diagResponse))
+		case "/status.xml":
+			w.Write([]byte(statusResponse))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			w.WriteHeader(404)
+		}
+	})
+	defer server.Close()
+
+	report, err := device.Describe(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"Model:     L4\n",
+		"Firmware:  CPU R2.2.25, driver N/A\n",
+		"  0: 450nm      0.0% (0)\n",
+		"660nm",
+		"735nm",
+		"5700K",
+		"Temps:     26.0C\n",
+		"Uptime:    2h39m37s\n",
+		"Schedule:  Not running\n",
+		"Role:      Independent\n",
+		"IP 192.168.1.8",
+	} {
+		if !strings.Contains(report, expected) {
+			t.Errorf("expected report to contain %q, got:\n%s", expected, report)
+		}
+	}
+}
+
+func TestDevice_DescribeDiagnostic(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	device, server := newTestDevice(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/status.xml" {
+			t.Errorf("expected only a request to /status.xml, got %s", r.URL.Path)
+			w.WriteHeader(404)
+			return
+		}
+		w.Write([]byte(statusResponse))
+	})
+	defer server.Close()
+
+	diag := &Diagnostic{}
+	if err := xml.Unmarshal([]byte(diagResponse), diag); err != nil {
+		t.Fatal(err)
+	}
+	diag.Model = "L4A"
+	report, err := device.DescribeDiagnostic(ctx, diag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(report, "Model:     L4A\n") {
+		t.Errorf("expected report to use the given diagnostic, got:\n%s", report)
+	}
+}
+
+func TestDescribe_Fields(t *testing.T) {
+	diag := &Diagnostic{MasterOrSlave: "Slave", Masters: "64:1A:10:10:10:10,"}
+	status := &Status{Temp: "0:26.8C,1:31.0C,", Uptime: "1d 00h 00m 05s"}
+	report, err := describe(diag, status)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"Temps:     26.8C, 31.0C\n",
+		"Uptime:    24h0m5s\n",
+		"Role:      Slave of master 64:1a:10:10:10:10\n",
+	} {
+		if !strings.Contains(report, expected) {
+			t.Errorf("expected report to contain %q, got:\n%s", expected, report)
+		}
+	}
+
+	// fields that can't be parsed are reported as they are
+	diag = &Diagnostic{MasterOrSlave: "Observer"}
+	status = &Status{Temp: "warm", Uptime: "a while"}
+	report, err = describe(diag, status)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"Temps:     warm\n", "Uptime:    a while\n", "Role:      Observer\n"} {
+		if !strings.Contains(report, expected) {
+			t.Errorf("expected report to contain %q, got:\n%s", expected, report)
+		}
+	}
+}
@@ -3,20 +3,81 @@ package heliospectra
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/xml"
 	"errors"
 	"fmt"
+	"io"
+	"log"
+	"math"
 	"net"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 )
 
 // Device is a Heliospectra LED device.
 type Device struct {
+	// HTTPTimeout, if non-zero, limits the duration of each HTTP request made
+	// to the Device, independent of any deadline on the caller's context.
+	HTTPTimeout time.Duration
+	// Logger receives errors that aren't returned to the caller, such as those
+	// from SetIntensitiesBestEffort. If nil, the standard logger is used.
+	Logger *log.Logger
+	// CheckResponse, if set, is called with the path and body of every
+	// successful response from the Device. A non-nil error is returned to the
+	// caller in place of the response. This lets callers detect firmware that
+	// reports failures, such as a rejected change on a locked fixture, in a
+	// body sent with a 200 status.
+	CheckResponse func(path string, body []byte) error
+	// OnRequest, if set, is called with every HTTP request before it's sent to
+	// the Device. The request's context carries the caller's context values,
+	// including any tag added with WithRequestTag.
+	OnRequest func(req *http.Request)
+	// LocalAddr, if set, is the local address HTTP connections to the Device
+	// are made from, which picks the network interface used on multi-homed
+	// hosts. It requires the Device's client to use an *http.Transport (or
+	// the default transport), which is copied and modified to bind the
+	// address.
+	LocalAddr net.IP
+	// ClampIntensities makes SetIntensities clamp out of range intensities
+	// into [0, MaxIntensity], logging each one to the Logger, rather than
+	// returning an error.
+	ClampIntensities bool
+	// Endpoints overrides the paths of the Device's HTTP endpoints.
+	Endpoints Endpoints
+	// MinIntensities is an optional per-channel floor, such as to keep crops
+	// from going completely dark. Every method that sets intensities raises
+	// any intensity below its channel's floor up to it, logging each one to
+	// the Logger. Channels beyond the end of the slice have no floor.
+	MinIntensities []int
+	// Validate makes SetIntensities check the number of intensities against
+	// the wavelengths in the Device's most recent Diagnostic, if one has been
+	// fetched, returning an error without sending anything on a mismatch.
+	Validate bool
+
 	addr   net.IP
+	port   int    // HTTP port, or 0 for the scheme's default
+	scheme string // "http" if empty
+	mac    net.HardwareAddr
+	serial string
 	client *http.Client
+
+	retryAttempts int // total attempts per request; 0 or 1 for no retries
+	retryBackoff  time.Duration
+
+	mu          sync.Mutex
+	done        chan struct{} // closed by Close
+	closed      bool
+	localClient *http.Client // client bound to localIP
+	localIP     net.IP
+	diag        *Diagnostic // most recent Diagnostic, for Validate
+	channels    int         // channel count from the last AllOff
 }
 
 // NewDevice creates a new device from an IP address. If client is nil, the
@@ -28,109 +89,466 @@ func NewDevice(addr net.IP, client *http.Client) *Device {
 	return &Device{addr: addr, client: client}
 }
 
-// Diagnostic executes a diagnostic request against the Device.
-func (d *Device) Diagnostic(ctx context.Context) (*Diagnostic, error) {
+// NewDeviceFromInfo creates a new device from a scan result, with its IP
+// address, MAC address and serial number. If client is nil, the
+// http.DefaultClient is used.
+func NewDeviceFromInfo(di DeviceInfo, client *http.Client) (*Device, error) {
+	if di.IPAddr == nil {
+		return nil, fmt.Errorf("device %s has no IP address", di.SerialNum)
+	}
+	mac, err := net.ParseMAC(strings.TrimSpace(di.MAC))
+	if err != nil {
+		return nil, err
+	}
+	d := NewDevice(di.IPAddr, client)
+	d.mac = mac
+	d.serial = di.SerialNum
+	return d, nil
+}
+
+// Serial returns the Device's serial number, or "" if it isn't known.
+func (d *Device) Serial() string {
+	return d.serial
+}
+
+// MAC returns the Device's MAC address, or nil if it isn't known.
+func (d *Device) MAC() net.HardwareAddr {
+	return d.mac
+}
+
+// SetMAC sets the Device's MAC address, which is needed to send it commands
+// over UDP, such as Restart.
+func (d *Device) SetMAC(mac net.HardwareAddr) {
+	d.mac = mac
+}
+
+// logf logs a message to the Device's Logger, prefixed with ctx's request tag
+// if it has one.
+func (d *Device) logf(ctx context.Context, format string, args ...interface{}) {
+	if tag, ok := RequestTag(ctx); ok {
+		format = "[%s] " + format
+		args = append([]interface{}{tag}, args...)
+	}
+	if d.Logger != nil {
+		d.Logger.Printf(format, args...)
+		return
+	}
+	log.Printf(format, args...)
+}
+
+// httpClient returns the client to use for requests, which is bound to
+// LocalAddr if it's set.
+func (d *Device) httpClient() (*http.Client, error) {
+	if d.LocalAddr == nil {
+		return d.client, nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.localClient != nil && d.localIP.Equal(d.LocalAddr) {
+		return d.localClient, nil
+	}
+
+	var transport *http.Transport
+	switch rt := d.client.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = rt.Clone()
+	default:
+		return nil, fmt.Errorf("unable to bind local address %s: client transport %T is not an *http.Transport", d.LocalAddr, rt)
+	}
+	transport.DialContext = localAddrDialer(d.LocalAddr).DialContext
+
+	client := *d.client
+	client.Transport = transport
+	d.localClient = &client
+	d.localIP = d.LocalAddr
+	return d.localClient, nil
+}
+
+// localAddrDialer returns a dialer for TCP connections from the local address
+// ip.
+func localAddrDialer(ip net.IP) *net.Dialer {
+	return &net.Dialer{
+		LocalAddr: &net.TCPAddr{IP: ip},
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+}
+
+type requestTagKey struct{}
+
+// WithRequestTag returns a copy of ctx carrying tag, such as a correlation ID or
+// the name of the operator making a change. Devices include the tag in their
+// log messages, and it can be read back with RequestTag in an OnRequest hook.
+func WithRequestTag(ctx context.Context, tag string) context.Context {
+	return context.WithValue(ctx, requestTagKey{}, tag)
+}
+
+// RequestTag returns the tag added to ctx with WithRequestTag, if any.
+func RequestTag(ctx context.Context) (string, bool) {
+	tag, ok := ctx.Value(requestTagKey{}).(string)
+	return tag, ok
+}
+
+// HTTPStatusError is returned when a Device responds to a request with a
+// non-2xx status. Callers can use errors.As to tell, say, a 404 from firmware
+// that doesn't serve Path apart from a 503 from a busy device.
+type HTTPStatusError struct {
+	StatusCode int
+	Path       string
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("unexpected status code %d from %s", e.StatusCode, e.Path)
+}
+
+// get performs a GET request for path with the given query against the Device
+// and returns the response body, retrying transient failures if the Device was
+// configured WithRetry.
+func (d *Device) get(ctx context.Context, path string, query url.Values) ([]byte, error) {
+	backoff := d.retryBackoff
+	for attempt := 1; ; attempt++ {
+		body, err := d.getOnce(ctx, path, query)
+		if err == nil || attempt >= d.retryAttempts || !retryable(err) || ctx.Err() != nil {
+			return body, err
+		}
+		d.logf(ctx, "heliospectra: retrying %s on %s after attempt %d: %s", path, d.addr, attempt, err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+// retryable reports whether err is a transient failure worth retrying: a
+// connection error, a timed out attempt or a 5xx response. Certificate and
+// other TLS failures are permanent, as are errors building the request.
+func retryable(err error) bool {
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500
+	}
+	var (
+		unknownAuthErr x509.UnknownAuthorityError
+		hostnameErr    x509.HostnameError
+		certErr        x509.CertificateInvalidError
+		recordErr      tls.RecordHeaderError
+	)
+	if errors.As(err, &unknownAuthErr) || errors.As(err, &hostnameErr) || errors.As(err, &certErr) || errors.As(err, &recordErr) {
+		return false
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		// TLS alerts from the server are reported as a "remote error"
+		return opErr.Op != "remote error"
+	}
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
+
+// getOnce performs a single GET request for path with the given query against
+// the Device and returns the response body. Any 2xx status is treated as
+// success, since some firmware responds to a successful request with 204 No
+// Content. Redirects are followed according to the client's policy; a 3xx
+// response that reaches this point wasn't followed and is returned as an
+// error.
+func (d *Device) getOnce(ctx context.Context, path string, query url.Values) ([]byte, error) {
+	if d.HTTPTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.HTTPTimeout)
+		defer cancel()
+	}
+
 	u := url.URL{
-		Host:   d.addr.String(),
-		Scheme: "http",
-		Path:   "diag.xml",
+		Host:     d.addr.String(),
+		Scheme:   d.scheme,
+		Path:     path,
+		RawQuery: query.Encode(),
+	}
+	if u.Scheme == "" {
+		u.Scheme = "http"
+	}
+	if d.port != 0 {
+		u.Host = net.JoinHostPort(u.Host, strconv.Itoa(d.port))
 	}
-	req, err := http.NewRequest("GET", u.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
 	if err != nil {
 		return nil, err
 	}
-	req.WithContext(ctx)
 	req.Header.Set("Connection", "close")
+	if d.OnRequest != nil {
+		d.OnRequest(req)
+	}
 
-	res, err := d.client.Do(req)
+	client, err := d.httpClient()
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer res.Body.Close()
 
-	if res.StatusCode != 200 {
-		return nil, fmt.Errorf("unexpected status code %d", res.StatusCode)
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, &HTTPStatusError{StatusCode: res.StatusCode, Path: path}
+	}
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if d.CheckResponse != nil {
+		if err := d.CheckResponse(path, body); err != nil {
+			return nil, err
+		}
+	}
+	return body, nil
+}
+
+// Diagnostic executes a diagnostic request against the Device.
+func (d *Device) Diagnostic(ctx context.Context) (*Diagnostic, error) {
+	body, err := d.get(ctx, d.Endpoints.diagnostic(), nil)
+	if err != nil {
+		return nil, err
 	}
 	diag := &Diagnostic{}
-	if err = xml.NewDecoder(res.Body).Decode(diag); err != nil {
+	if err = xml.Unmarshal(body, diag); err != nil {
 		return nil, err
 	}
+	d.mu.Lock()
+	d.diag = diag
+	d.mu.Unlock()
 	return diag, nil
 }
 
+// cachedDiagnostic returns the Device's most recent Diagnostic, or nil if none
+// has been fetched.
+func (d *Device) cachedDiagnostic() *Diagnostic {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.diag
+}
+
+// MaxIntensity is the highest intensity a wavelength can be set to. Intensities
+// range from 0 (off) to MaxIntensity (full power).
+const MaxIntensity = 1000
+
 // SetIntensities sets the intensities for each wavelength of this Device. You
 // must provide the same number of intensities as the number of distinct
-// wavelengths this Device has.
+// wavelengths this Device has. The count isn't checked, which suits callers
+// that have already cached it; use SetIntensitiesChecked otherwise.
+//
+// Intensities must be in the range [0, MaxIntensity]. An out of range intensity
+// is an error, and nothing is sent, unless the Device's ClampIntensities option
+// is set. With the Validate option, the number of intensities is checked too.
+// Intensities below the Device's MinIntensities are raised to them.
 func (d *Device) SetIntensities(ctx context.Context, intensities ...int) error {
-	var buf bytes.Buffer
+	adjusted, err := d.adjustIntIntensities(ctx, intensities, false)
+	if err != nil {
+		return err
+	}
+	return d.sendIntensities(ctx, adjusted)
+}
+
+// adjustIntensities applies the checks and adjustments described by
+// SetIntensities to intensities, and returns the intensities to send: with the
+// Validate option, their count is checked against the cached diagnostic; out
+// of range intensities are an error, or are clamped if clamp or the
+// ClampIntensities option is set; and intensities below MinIntensities are
+// raised to them. Only clamping that clamp didn't ask for is logged.
+func (d *Device) adjustIntensities(ctx context.Context, intensities []float64, clamp bool) ([]float64, error) {
+	if d.Validate {
+		if diag := d.cachedDiagnostic(); diag != nil {
+			if err := checkIntensityCount(diag, len(intensities)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	adjusted := make([]float64, len(intensities))
 	for i, intensity := range intensities {
-		if i != 0 {
-			if err := buf.WriteByte(':'); err != nil {
-				return err
+		if math.IsNaN(intensity) || intensity < 0 || intensity > MaxIntensity {
+			if !clamp && !d.ClampIntensities {
+				return nil, fmt.Errorf("intensity %v at channel %d out of range [0,%d]", intensity, i, MaxIntensity)
+			}
+			clamped := clampIntensityFloat(intensity)
+			if !clamp {
+				d.logf(ctx, "heliospectra: clamping intensity %v at channel %d to %v", intensity, i, clamped)
 			}
+			intensity = clamped
 		}
-		if _, err := fmt.Fprintf(&buf, "%d", intensity); err != nil {
-			return err
+		if i < len(d.MinIntensities) && intensity < float64(d.MinIntensities[i]) {
+			d.logf(ctx, "heliospectra: raising intensity %v at channel %d to minimum %d", intensity, i, d.MinIntensities[i])
+			intensity = float64(d.MinIntensities[i])
 		}
+		adjusted[i] = intensity
 	}
-	u := url.URL{
-		Host:   d.addr.String(),
-		Scheme: "http",
-		Path:   "intensity.cgi",
-	}
-	q := u.Query()
-	q.Set("int", buf.String())
-	u.RawQuery = q.Encode()
+	return adjusted, nil
+}
 
-	req, err := http.NewRequest("GET", u.String(), nil)
+// adjustIntIntensities is adjustIntensities for whole intensities, which stay
+// whole after being adjusted.
+func (d *Device) adjustIntIntensities(ctx context.Context, intensities []int, clamp bool) ([]int, error) {
+	adjusted, err := d.adjustIntensities(ctx, intsToFloats(intensities), clamp)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	req.WithContext(ctx)
-	req.Header.Set("Connection", "close")
+	ints := make([]int, len(adjusted))
+	for i, intensity := range adjusted {
+		ints[i] = int(intensity)
+	}
+	return ints, nil
+}
 
-	res, err := d.client.Do(req)
-	if err != nil {
+// sendIntensities sends already adjusted whole intensities to the intensity
+// endpoint.
+func (d *Device) sendIntensities(ctx context.Context, intensities []int) error {
+	values := make([]string, len(intensities))
+	for i, intensity := range intensities {
+		values[i] = strconv.Itoa(intensity)
+	}
+	return d.setIntensities(ctx, values)
+}
+
+func intsToFloats(intensities []int) []float64 {
+	floats := make([]float64, len(intensities))
+	for i, intensity := range intensities {
+		floats[i] = float64(intensity)
+	}
+	return floats
+}
+
+// SetIntensitiesChecked is like SetIntensities, but returns an error without
+// making a request if the number of intensities doesn't match the number of
+// wavelengths in diag.
+func (d *Device) SetIntensitiesChecked(ctx context.Context, diag *Diagnostic, intensities ...int) error {
+	if err := checkIntensityCount(diag, len(intensities)); err != nil {
 		return err
 	}
-	defer res.Body.Close()
+	return d.SetIntensities(ctx, intensities...)
+}
 
-	if res.StatusCode != 200 {
-		return fmt.Errorf("unexpected status code %d", res.StatusCode)
+func checkIntensityCount(diag *Diagnostic, count int) error {
+	if count != diag.ChannelCount() {
+		return fmt.Errorf("got %d intensities but device has %d wavelengths", count, diag.ChannelCount())
 	}
 	return nil
 }
 
-// Status executes a status request against the Device.
-func (d *Device) Status(ctx context.Context) (*Status, error) {
-	u := url.URL{
-		Host:   d.addr.String(),
-		Scheme: "http",
-		Path:   "status.xml",
-	}
-	req, err := http.NewRequest("GET", u.String(), nil)
+// SetIntensitiesClamped is like SetIntensities, but first clamps each
+// intensity to the range [0, MaxIntensity]. It returns the intensities that
+// were sent, after clamping and raising to MinIntensities, so callers can see
+// what was adjusted.
+func (d *Device) SetIntensitiesClamped(ctx context.Context, intensities ...int) ([]int, error) {
+	adjusted, err := d.adjustIntIntensities(ctx, intensities, true)
 	if err != nil {
 		return nil, err
 	}
-	req.WithContext(ctx)
-	req.Header.Set("Connection", "close")
+	return adjusted, d.sendIntensities(ctx, adjusted)
+}
 
-	res, err := d.client.Do(req)
+// clampIntensityFloat clamps intensity to the range [0, MaxIntensity]. NaN is
+// clamped to 0.
+func clampIntensityFloat(intensity float64) float64 {
+	if math.IsNaN(intensity) {
+		return 0
+	}
+	return math.Max(0, math.Min(MaxIntensity, intensity))
+}
+
+// SetIntensitiesFloat is like SetIntensities, but accepts fractional
+// intensities which are sent with one decimal place of precision. Not all
+// firmware supports fractional intensities: those that don't are expected to
+// reject the request with a non-200 status, which is returned as an error.
+// Callers that need to be certain the values were applied should read them
+// back with Status. Intensities are checked and adjusted the same way as by
+// SetIntensities.
+func (d *Device) SetIntensitiesFloat(ctx context.Context, intensities ...float64) error {
+	adjusted, err := d.adjustIntensities(ctx, intensities, false)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer res.Body.Close()
+	values := make([]string, len(adjusted))
+	for i, intensity := range adjusted {
+		values[i] = strconv.FormatFloat(intensity, 'f', 1, 64)
+	}
+	return d.setIntensities(ctx, values)
+}
+
+// setIntensities sends the already formatted intensity values to the intensity
+// endpoint.
+func (d *Device) setIntensities(ctx context.Context, values []string) error {
+	q := url.Values{}
+	q.Set("int", strings.Join(values, ":"))
+
+	_, err := d.get(ctx, d.Endpoints.intensity(), q)
+	return err
+}
 
-	if res.StatusCode != 200 {
-		return nil, fmt.Errorf("unexpected status code %d", res.StatusCode)
+// SetIntensitiesBestEffort is like SetIntensities, but instead of returning an
+// error it logs it to the Device's Logger. It's intended for rapid animation
+// loops where a single failed update isn't worth stopping for.
+func (d *Device) SetIntensitiesBestEffort(ctx context.Context, intensities ...int) {
+	if err := d.SetIntensities(ctx, intensities...); err != nil {
+		d.logf(ctx, "heliospectra: setting intensities on %s: %s", d.addr, err)
+	}
+}
+
+// Status executes a status request against the Device.
+func (d *Device) Status(ctx context.Context) (*Status, error) {
+	body, err := d.get(ctx, d.Endpoints.status(), nil)
+	if err != nil {
+		return nil, err
 	}
 	status := &Status{}
-	if err = xml.NewDecoder(res.Body).Decode(status); err != nil {
+	if err = xml.Unmarshal(body, status); err != nil {
 		return nil, err
 	}
 	return status, nil
 }
 
+// DetectContention reports whether the fixture's light settings were last
+// changed after since by a source other than myIP, such as a person using the
+// web interface. Automation can use this to back off rather than fight over a
+// fixture. A change without a source address is also treated as contention.
+// The device reports its last change in local time without a zone, so it's
+// interpreted in since's location.
+func (d *Device) DetectContention(ctx context.Context, myIP net.IP, since time.Time) (bool, error) {
+	status, err := d.Status(ctx)
+	if err != nil {
+		return false, err
+	}
+	changedAt, err := parseChangeTime(status.LastChangeAt, since.Location())
+	if err != nil {
+		return false, err
+	}
+	if !changedAt.After(since) {
+		return false, nil
+	}
+	return !status.LastChangeBy.Equal(myIP), nil
+}
+
+// RawStatus executes a status request against the Device and returns the
+// unparsed status.xml body, for callers that want to decode it themselves.
+func (d *Device) RawStatus(ctx context.Context) ([]byte, error) {
+	return d.get(ctx, d.Endpoints.status(), nil)
+}
+
+// GetIntensities returns the Device's current intensities, ordered by
+// wavelength number. It reads status.xml, which is cheaper than a full
+// Diagnostic.
+func (d *Device) GetIntensities(ctx context.Context) ([]int, error) {
+	status, err := d.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return status.ParsedIntensities()
+}
+
 // WavelengthDescription is a description of an available wavelength on a Device.
 type WavelengthDescription struct {
 	Number     uint8
@@ -138,9 +556,65 @@ type WavelengthDescription struct {
 	Power      string
 }
 
+// PowerWatts parses the wavelength's power, such as "5.2W", in watts.
+func (wd WavelengthDescription) PowerWatts() (float64, error) {
+	return parseUnitFloat(wd.Power, "W")
+}
+
+// WavelengthNanometers returns the wavelength in nanometers, such as 660 for
+// "660nm". It returns false for channels that aren't described by a
+// wavelength, such as white channels described by a color temperature.
+func (wd WavelengthDescription) WavelengthNanometers() (int, bool) {
+	return parseNanometers(wd.Wavelength)
+}
+
+// ColorTempKelvin returns the color temperature of a white channel in kelvin,
+// such as 5700 for "5700K". It returns false for channels described by a
+// wavelength instead.
+func (wd WavelengthDescription) ColorTempKelvin() (int, bool) {
+	if !strings.HasSuffix(wd.Wavelength, "K") {
+		return 0, false
+	}
+	k, err := strconv.Atoi(strings.TrimSuffix(wd.Wavelength, "K"))
+	if err != nil || k <= 0 {
+		return 0, false
+	}
+	return k, true
+}
+
 // WavelengthList is a list of WavelengthDescriptions.
 type WavelengthList []WavelengthDescription
 
+// ByNumber returns the WavelengthDescription with the given channel number, and
+// whether it was found.
+func (wl WavelengthList) ByNumber(n uint8) (WavelengthDescription, bool) {
+	for _, desc := range wl {
+		if desc.Number == n {
+			return desc, true
+		}
+	}
+	return WavelengthDescription{}, false
+}
+
+// ByWavelength returns the first WavelengthDescription with the given
+// wavelength label, such as "660nm" or "5700K", and whether it was found.
+// Labels are compared case-insensitively.
+func (wl WavelengthList) ByWavelength(label string) (WavelengthDescription, bool) {
+	for _, desc := range wl {
+		if strings.EqualFold(desc.Wavelength, label) {
+			return desc, true
+		}
+	}
+	return WavelengthDescription{}, false
+}
+
+// Contains reports whether the list has a channel with the given wavelength
+// label, such as "660nm" or "5700K". Labels are compared case-insensitively.
+func (wl WavelengthList) Contains(label string) bool {
+	_, ok := wl.ByWavelength(label)
+	return ok
+}
+
 // UnmarshalXML unmarshals a list of WavelengthDescriptions from XML.
 func (wl *WavelengthList) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 	var val string
@@ -217,6 +691,26 @@ type Diagnostic struct {
 	Tags           string         `xml:"tags"`
 }
 
+// MatchesDeviceInfo reports whether di, as returned by a UDP scan, describes
+// the same fixture as this Diagnostic. The diagnostic doesn't include a serial
+// number, so the fixture's ethernet and wlan MAC addresses are compared
+// against di.MAC instead, ignoring differences in case and formatting.
+func (d *Diagnostic) MatchesDeviceInfo(di DeviceInfo) bool {
+	mac, err := net.ParseMAC(di.MAC)
+	if err != nil {
+		return false
+	}
+	for _, s := range []string{d.EthernetMAC, d.WlanMAC} {
+		if s == "" {
+			continue
+		}
+		if other, err := net.ParseMAC(s); err == nil && bytes.Equal(mac, other) {
+			return true
+		}
+	}
+	return false
+}
+
 // Status is the response to a status.xml call.
 type Status struct {
 	InternalTime        string `xml:"a"`
@@ -233,4 +727,6 @@ type Status struct {
 	Reserved            string `xml:"l"`
 	ControlMode         string `xml:"m"`
 	NTPTimeSettings     string `xml:"q"`
+	NTPEnabled          string `xml:"s"`
+	PowerDraw           string `xml:"t"`
 }
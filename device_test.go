@@ -1,7 +1,13 @@
 package heliospectra
 
 import (
+	"bytes"
 	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"log"
+	"math"
 	"net"
 	"net/http"
 	"net/http/httptest"
@@ -10,6 +16,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/bgentry/heliospectra/heliospectratest"
 )
 
 func TestNewDevice(t *testing.T) {
@@ -27,6 +35,51 @@ func TestNewDevice(t *testing.T) {
 	}
 }
 
+func TestNewDeviceFromInfo(t *testing.T) {
+	di := DeviceInfo{
+		MAC:       "64:1A:10:10:10:10",
+		IPAddr:    net.IPv4(192, 168, 1, 8),
+		SerialNum: "abc123",
+	}
+	d, err := NewDeviceFromInfo(di, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.addr.Equal(di.IPAddr) {
+		t.Errorf("expected IP %s, got %s", di.IPAddr, d.addr)
+	}
+	if expected := (net.HardwareAddr{0x64, 0x1a, 0x10, 0x10, 0x10, 0x10}); !reflect.DeepEqual(expected, d.MAC()) {
+		t.Errorf("expected MAC %s, got %s", expected, d.MAC())
+	}
+	if d.Serial() != "abc123" {
+		t.Errorf("expected serial abc123, got %q", d.Serial())
+	}
+	if d.client != http.DefaultClient {
+		t.Errorf("expected DefaultClient to be used")
+	}
+
+	if d := NewDevice(net.IPv4(1, 2, 3, 4), nil); d.MAC() != nil {
+		t.Errorf("expected NewDevice to leave the MAC unset, got %s", d.MAC())
+	}
+
+	for _, bad := range []DeviceInfo{
+		{MAC: "not-a-mac", IPAddr: net.IPv4(192, 168, 1, 8)},
+		{MAC: "64:1A:10:10:10:10"},
+	} {
+		if _, err := NewDeviceFromInfo(bad, nil); err == nil {
+			t.Errorf("NewDeviceFromInfo(%+v): expected an error, got none", bad)
+		}
+	}
+}
+
+// newTestDevice returns a Device whose requests are all sent to a test server
+// running handler. The server must be closed by the caller.
+func newTestDevice(t *testing.T, handler http.HandlerFunc) (*Device, *httptest.Server) {
+	server := httptest.NewServer(handler)
+	testIP := net.IPv4(192, 168, 1, 8)
+	return NewDevice(testIP, heliospectratest.RedirectClientPort(t, server.URL, testIP, 80)), server
+}
+
 const diagResponse = `
 <diagnostic>
 	<model>L4</model>
@@ -98,22 +151,7 @@ func TestDevice_Diagnostic(t *testing.T) {
 
 	testIP := net.IPv4(192, 168, 1, 8)
 
-	client := &http.Client{
-		Transport: &http.Transport{
-			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
-				expectedAddr := testIP.String() + ":80"
-				if addr != expectedAddr {
-					t.Errorf("expected request to be sent to %s, was sent to %s", expectedAddr, addr)
-				}
-				// override the DialContext func to only dial to our test server:
-				return (&net.Dialer{
-					Timeout:   1 * time.Second,
-					KeepAlive: 1 * time.Second,
-					DualStack: false,
-				}).DialContext(ctx, network, strings.TrimPrefix(server.URL, "http://"))
-			},
-		},
-	}
+	client := heliospectratest.RedirectClientPort(t, server.URL, testIP, 80)
 
 	device := NewDevice(testIP, client)
 	diag, err := device.Diagnostic(ctx)
@@ -134,12 +172,16 @@ func TestDevice_Diagnostic(t *testing.T) {
 		t.Errorf("expected wavelengths=%#v\n\tgot %#v", expWavelengths, diag.Wavelengths)
 		t.Logf("DIAG: %+v\n", diag)
 	}
+	if diag.WLANIP != nil {
+		t.Errorf("expected blank wlanIP to decode to nil, got %#v", diag.WLANIP)
+	}
+	if expIP := net.IPv4(192, 168, 1, 8); !reflect.DeepEqual(expIP, diag.EthernetIP) {
+		t.Errorf("expected ethernetIP=%s, got %s", expIP, diag.EthernetIP)
+	}
 
 	statusToReturn = 400
 	_, err = device.Diagnostic(ctx)
-	if err == nil {
-		t.Errorf("expected an error on status 400, got none")
-	}
+	assertHTTPStatusError(t, err, 400, "diag.xml")
 
 	statusToReturn = 200
 	bodyToReturn = `{"some":"json"}`
@@ -148,6 +190,144 @@ func TestDevice_Diagnostic(t *testing.T) {
 	}
 }
 
+func TestDevice_ContextCancellation(t *testing.T) {
+	started := make(chan struct{}, 1)
+	device, server := newTestDevice(t, func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+	defer server.Close()
+
+	calls := map[string]func(ctx context.Context) error{
+		"Diagnostic": func(ctx context.Context) error {
+			_, err := device.Diagnostic(ctx)
+			return err
+		},
+		"SetIntensities": func(ctx context.Context) error {
+			return device.SetIntensities(ctx, 1, 2, 3, 4)
+		},
+		"Status": func(ctx context.Context) error {
+			_, err := device.Status(ctx)
+			return err
+		},
+	}
+	for name, call := range calls {
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			<-started
+			cancel()
+		}()
+
+		start := time.Now()
+		err := call(ctx)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: expected a context cancellation error, got %v", name, err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("%s: expected call to return promptly after cancellation, took %s", name, elapsed)
+		}
+		cancel()
+	}
+}
+
+func TestDevice_LocalAddr(t *testing.T) {
+	local := net.IPv4(10, 0, 0, 5)
+	dialer := localAddrDialer(local)
+	if expected := (&net.TCPAddr{IP: local}); !reflect.DeepEqual(expected, dialer.LocalAddr) {
+		t.Errorf("expected dialer LocalAddr=%s, got %s", expected, dialer.LocalAddr)
+	}
+
+	device := NewDevice(net.IPv4(192, 168, 1, 8), nil)
+	client, err := device.httpClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client != http.DefaultClient {
+		t.Errorf("expected the Device's client to be used without LocalAddr")
+	}
+
+	device.LocalAddr = local
+	client, err = device.httpClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client == http.DefaultClient {
+		t.Fatal("expected a separate client when LocalAddr is set")
+	}
+	if _, ok := client.Transport.(*http.Transport); !ok {
+		t.Errorf("expected an *http.Transport, got %T", client.Transport)
+	}
+	if again, _ := device.httpClient(); again != client {
+		t.Errorf("expected the bound client to be reused")
+	}
+
+	device = NewDevice(net.IPv4(192, 168, 1, 8), &http.Client{Transport: roundTripperFunc(nil)})
+	device.LocalAddr = local
+	if _, err := device.httpClient(); err == nil {
+		t.Errorf("expected an error binding LocalAddr with a custom RoundTripper, got none")
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestDevice_HTTPTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	device, server := newTestDevice(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+	defer server.Close()
+	device.HTTPTimeout = 50 * time.Millisecond
+
+	start := time.Now()
+	if _, err := device.Diagnostic(ctx); err == nil {
+		t.Fatalf("expected an error when HTTPTimeout is exceeded, got none")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected request to abort after HTTPTimeout, took %s", elapsed)
+	}
+	if ctx.Err() != nil {
+		t.Errorf("expected the caller's context to still be active")
+	}
+}
+
+func TestDiagnostic_MatchesDeviceInfo(t *testing.T) {
+	diag := &Diagnostic{EthernetMAC: "64:1a:00:00:00:00"}
+
+	tests := []struct {
+		mac      string
+		expected bool
+	}{
+		{mac: "64:1a:00:00:00:00", expected: true},
+		{mac: "64:1A:00:00:00:00", expected: true},
+		{mac: "64-1a-00-00-00-00", expected: true},
+		{mac: "64:1a:00:00:00:01", expected: false},
+		{mac: "", expected: false},
+		{mac: "not a mac", expected: false},
+	}
+	for _, tt := range tests {
+		if got := diag.MatchesDeviceInfo(DeviceInfo{MAC: tt.mac}); got != tt.expected {
+			t.Errorf("MatchesDeviceInfo(%q): expected %t, got %t", tt.mac, tt.expected, got)
+		}
+	}
+
+	diag = &Diagnostic{WlanMAC: "64:1a:00:00:00:01"}
+	if !diag.MatchesDeviceInfo(DeviceInfo{MAC: "64:1A:00:00:00:01"}) {
+		t.Errorf("expected DeviceInfo to match on wlan MAC")
+	}
+}
+
 func TestDevice_SetIntensities(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -175,22 +355,7 @@ func TestDevice_SetIntensities(t *testing.T) {
 
 	testIP := net.IPv4(192, 168, 1, 8)
 
-	client := &http.Client{
-		Transport: &http.Transport{
-			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
-				expectedAddr := testIP.String() + ":80"
-				if addr != expectedAddr {
-					t.Errorf("expected request to be sent to %s, was sent to %s", expectedAddr, addr)
-				}
-				// override the DialContext func to only dial to our test server:
-				return (&net.Dialer{
-					Timeout:   1 * time.Second,
-					KeepAlive: 1 * time.Second,
-					DualStack: false,
-				}).DialContext(ctx, network, strings.TrimPrefix(server.URL, "http://"))
-			},
-		},
-	}
+	client := heliospectratest.RedirectClientPort(t, server.URL, testIP, 80)
 
 	device := NewDevice(testIP, client)
 	if err := device.SetIntensities(ctx, 1, 2, 3, 4); err != nil {
@@ -198,8 +363,374 @@ func TestDevice_SetIntensities(t *testing.T) {
 	}
 
 	statusToReturn = 400
-	if err := device.SetIntensities(ctx, 1, 2, 3, 4); err == nil {
-		t.Errorf("expected an error on status 400, got none")
+	assertHTTPStatusError(t, device.SetIntensities(ctx, 1, 2, 3, 4), 400, "intensity.cgi")
+}
+
+func TestDevice_SetIntensities_StatusCodes(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	statusToReturn := 204
+	device, server := newTestDevice(t, func(w http.ResponseWriter, r *http.Request) {
+		if statusToReturn >= 300 && statusToReturn < 400 {
+			w.Header().Set("Location", "/elsewhere")
+		}
+		w.WriteHeader(statusToReturn)
+	})
+	defer server.Close()
+	device.client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	for _, status := range []int{200, 202, 204, 206} {
+		statusToReturn = status
+		if err := device.SetIntensities(ctx, 1, 2, 3, 4); err != nil {
+			t.Errorf("expected status %d to be treated as success, got %s", status, err)
+		}
+	}
+	for _, status := range []int{302, 304, 404, 500, 503} {
+		statusToReturn = status
+		assertHTTPStatusError(t, device.SetIntensities(ctx, 1, 2, 3, 4), status, "intensity.cgi")
+	}
+}
+
+// assertHTTPStatusError checks that err is an *HTTPStatusError for the given
+// status code and path.
+func assertHTTPStatusError(t *testing.T, err error, statusCode int, path string) {
+	t.Helper()
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) {
+		t.Errorf("expected an *HTTPStatusError on status %d, got %#v", statusCode, err)
+		return
+	}
+	if statusErr.StatusCode != statusCode || statusErr.Path != path {
+		t.Errorf("expected status %d from %s, got %d from %s", statusCode, path, statusErr.StatusCode, statusErr.Path)
+	}
+}
+
+func TestDevice_CheckResponse(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	bodyToReturn := "ERROR: fixture is locked"
+	device, server := newTestDevice(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(bodyToReturn))
+	})
+	defer server.Close()
+
+	errLocked := errors.New("fixture is locked")
+	var checkedPath string
+	device.CheckResponse = func(path string, body []byte) error {
+		checkedPath = path
+		if bytes.HasPrefix(body, []byte("ERROR")) {
+			return errLocked
+		}
+		return nil
+	}
+
+	if err := device.SetIntensities(ctx, 1, 2, 3, 4); err != errLocked {
+		t.Errorf("expected error %v for a 200 error body, got %v", errLocked, err)
+	}
+	if checkedPath != "intensity.cgi" {
+		t.Errorf("expected CheckResponse to be called with intensity.cgi, got %q", checkedPath)
+	}
+
+	bodyToReturn = ""
+	if err := device.SetIntensities(ctx, 1, 2, 3, 4); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestDevice_SetIntensities_Range(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var gotQuery string
+	requests := 0
+	device, server := newTestDevice(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		gotQuery = r.URL.Query().Get("int")
+	})
+	defer server.Close()
+
+	tests := []struct {
+		intensities []int
+		expectedErr string
+	}{
+		{intensities: []int{0, 1000, 500, 0}},
+		{intensities: []int{0, -1, 500, 0}, expectedErr: "intensity -1 at channel 1 out of range [0,1000]"},
+		{intensities: []int{0, 0, 0, 1001}, expectedErr: "intensity 1001 at channel 3 out of range [0,1000]"},
+	}
+	for _, tt := range tests {
+		requests = 0
+		err := device.SetIntensities(ctx, tt.intensities...)
+		if tt.expectedErr == "" {
+			if err != nil {
+				t.Errorf("SetIntensities(%v): unexpected error: %s", tt.intensities, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != tt.expectedErr {
+			t.Errorf("SetIntensities(%v): expected error %q, got %v", tt.intensities, tt.expectedErr, err)
+		}
+		if requests != 0 {
+			t.Errorf("SetIntensities(%v): expected no request, got %d", tt.intensities, requests)
+		}
+	}
+
+	var buf bytes.Buffer
+	device.Logger = log.New(&buf, "", 0)
+	device.ClampIntensities = true
+	if err := device.SetIntensities(ctx, -1, 1001, 0, 1000); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "0:1000:0:1000"; gotQuery != expected {
+		t.Errorf("expected int=%s, got %s", expected, gotQuery)
+	}
+	if !strings.Contains(buf.String(), "clamping intensity 1001 at channel 1 to 1000") {
+		t.Errorf("expected clamping to be logged, got %q", buf.String())
+	}
+}
+
+func TestDevice_SetIntensities_MinIntensities(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var gotQuery string
+	device, server := newTestDevice(t, func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.Query().Get("int")
+	})
+	defer server.Close()
+
+	var buf bytes.Buffer
+	device.Logger = log.New(&buf, "", 0)
+	device.MinIntensities = []int{100, 0, 50}
+	if err := device.SetIntensities(ctx, 0, 0, 200, 0); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "100:0:200:0"; gotQuery != expected {
+		t.Errorf("expected int=%s, got %s", expected, gotQuery)
+	}
+	if !strings.Contains(buf.String(), "raising intensity 0 at channel 0 to minimum 100") {
+		t.Errorf("expected the raised intensity to be logged, got %q", buf.String())
+	}
+	if strings.Count(buf.String(), "raising") != 1 {
+		t.Errorf("expected only one intensity to be raised, got %q", buf.String())
+	}
+}
+
+func TestDevice_SetIntensities_Validate(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	requests := 0
+	device, server := newTestDevice(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/diag.xml":
+			w.Write([]byte(diagResponse))
+		case "/intensity.cgi":
+			requests++
+		}
+	})
+	defer server.Close()
+	device.Validate = true
+
+	// without a diagnostic there's nothing to validate against
+	if err := device.SetIntensities(ctx, 1, 2, 3); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 1 {
+		t.Errorf("expected 1 request, got %d", requests)
+	}
+
+	if _, err := device.Diagnostic(ctx); err != nil {
+		t.Fatal(err)
+	}
+	requests = 0
+	if err := device.SetIntensities(ctx, 1, 2, 3); err == nil {
+		t.Errorf("expected an error for 3 intensities on a 4 channel device, got none")
+	}
+	if err := device.SetIntensitiesFloat(ctx, 1, 2, 3); err == nil {
+		t.Errorf("expected an error for 3 fractional intensities on a 4 channel device, got none")
+	}
+	if requests != 0 {
+		t.Errorf("expected no request on a count mismatch, got %d", requests)
+	}
+	if err := device.SetIntensities(ctx, 1, 2, 3, 4); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 1 {
+		t.Errorf("expected 1 request, got %d", requests)
+	}
+
+	device.Validate = false
+	if err := device.SetIntensities(ctx, 1, 2, 3); err != nil {
+		t.Errorf("expected no validation with Validate unset, got %s", err)
+	}
+}
+
+func TestDevice_SetIntensitiesChecked(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	requests := 0
+	device, server := newTestDevice(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	})
+	defer server.Close()
+
+	diag := &Diagnostic{Wavelengths: testWavelengths}
+	if err := device.SetIntensitiesChecked(ctx, diag, 1, 2, 3); err == nil {
+		t.Errorf("expected an error for 3 intensities on a 4 channel device, got none")
+	}
+	if requests != 0 {
+		t.Errorf("expected no request on a count mismatch, got %d", requests)
+	}
+
+	if err := device.SetIntensitiesChecked(ctx, diag, 1, 2, 3, 4); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 1 {
+		t.Errorf("expected 1 request, got %d", requests)
+	}
+}
+
+func TestDevice_SetIntensitiesClamped(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var gotQuery string
+	device, server := newTestDevice(t, func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.Query().Get("int")
+	})
+	defer server.Close()
+
+	clamped, err := device.SetIntensitiesClamped(ctx, -5, 500, 1000, 1200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []int{0, 500, 1000, 1000}; !reflect.DeepEqual(expected, clamped) {
+		t.Errorf("expected clamped intensities %v, got %v", expected, clamped)
+	}
+	if expected := "0:500:1000:1000"; gotQuery != expected {
+		t.Errorf("expected int=%s, got %s", expected, gotQuery)
+	}
+
+	// the returned intensities are the ones sent, after raising to the floor
+	device.Logger = log.New(io.Discard, "", 0)
+	device.MinIntensities = []int{100}
+	sent, err := device.SetIntensitiesClamped(ctx, -5, 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []int{100, 500}; !reflect.DeepEqual(expected, sent) {
+		t.Errorf("expected sent intensities %v, got %v", expected, sent)
+	}
+	if expected := "100:500"; gotQuery != expected {
+		t.Errorf("expected int=%s, got %s", expected, gotQuery)
+	}
+}
+
+func TestDevice_SetIntensitiesFloat(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var gotQuery string
+	device, server := newTestDevice(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/intensity.cgi" {
+			t.Errorf("expected URL /intensity.cgi, got %s", r.URL.Path)
+		}
+		gotQuery = r.URL.Query().Get("int")
+	})
+	defer server.Close()
+
+	if err := device.SetIntensitiesFloat(ctx, 0, 12.5, 100, 33.33); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "0.0:12.5:100.0:33.3"; gotQuery != expected {
+		t.Errorf("expected int=%s, got %s", expected, gotQuery)
+	}
+
+	gotQuery = ""
+	for _, intensities := range [][]float64{{-5}, {0, 1000.5}, {math.NaN()}} {
+		if err := device.SetIntensitiesFloat(ctx, intensities...); err == nil {
+			t.Errorf("SetIntensitiesFloat(%v): expected an error, got none", intensities)
+		}
+	}
+	if gotQuery != "" {
+		t.Errorf("expected out of range intensities not to be sent, got int=%s", gotQuery)
+	}
+
+	device.Logger = log.New(io.Discard, "", 0)
+	device.ClampIntensities = true
+	if err := device.SetIntensitiesFloat(ctx, -5, 1000.5, 12.5, 0); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "0.0:1000.0:12.5:0.0"; gotQuery != expected {
+		t.Errorf("expected int=%s, got %s", expected, gotQuery)
+	}
+
+	device.MinIntensities = []int{100, 0, 50}
+	if err := device.SetIntensitiesFloat(ctx, 0, 0, 12.5, 0); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "100.0:0.0:50.0:0.0"; gotQuery != expected {
+		t.Errorf("expected int=%s, got %s", expected, gotQuery)
+	}
+}
+
+func TestDevice_SetIntensitiesBestEffort(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	device, server := newTestDevice(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(400)
+	})
+	defer server.Close()
+
+	var buf bytes.Buffer
+	device.Logger = log.New(&buf, "", 0)
+	device.SetIntensitiesBestEffort(ctx, 1, 2, 3, 4)
+
+	if !strings.Contains(buf.String(), "unexpected status code 400") {
+		t.Errorf("expected the error to be logged, got %q", buf.String())
+	}
+}
+
+func TestDevice_RequestTag(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	device, server := newTestDevice(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(400)
+	})
+	defer server.Close()
+
+	var hookTags []string
+	device.OnRequest = func(req *http.Request) {
+		tag, _ := RequestTag(req.Context())
+		hookTags = append(hookTags, tag)
+	}
+	var buf bytes.Buffer
+	device.Logger = log.New(&buf, "", 0)
+
+	if _, ok := RequestTag(ctx); ok {
+		t.Errorf("expected no tag on an untagged context")
+	}
+	device.SetIntensitiesBestEffort(WithRequestTag(ctx, "operator-42"), 1, 2, 3, 4)
+
+	if expected := []string{"operator-42"}; !reflect.DeepEqual(expected, hookTags) {
+		t.Errorf("expected OnRequest to see tags %q, got %q", expected, hookTags)
+	}
+	if !strings.HasPrefix(buf.String(), "[operator-42] ") {
+		t.Errorf("expected log message to include the tag, got %q", buf.String())
+	}
+
+	// a tag is logged verbatim, not interpreted as part of the format
+	buf.Reset()
+	device.logf(WithRequestTag(ctx, "batch 100%s done"), "heliospectra: setting %d intensities", 4)
+	if expected := "[batch 100%s done] heliospectra: setting 4 intensities\n"; buf.String() != expected {
+		t.Errorf("expected log message %q, got %q", expected, buf.String())
 	}
 }
 
@@ -250,22 +781,7 @@ func TestDevice_Status(t *testing.T) {
 
 	testIP := net.IPv4(192, 168, 1, 8)
 
-	client := &http.Client{
-		Transport: &http.Transport{
-			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
-				expectedAddr := testIP.String() + ":80"
-				if addr != expectedAddr {
-					t.Errorf("expected request to be sent to %s, was sent to %s", expectedAddr, addr)
-				}
-				// override the DialContext func to only dial to our test server:
-				return (&net.Dialer{
-					Timeout:   1 * time.Second,
-					KeepAlive: 1 * time.Second,
-					DualStack: false,
-				}).DialContext(ctx, network, strings.TrimPrefix(server.URL, "http://"))
-			},
-		},
-	}
+	client := heliospectratest.RedirectClientPort(t, server.URL, testIP, 80)
 
 	device := NewDevice(testIP, client)
 	status, err := device.Status(ctx)
@@ -288,6 +804,8 @@ func TestDevice_Status(t *testing.T) {
 		Reserved:            " ",
 		ControlMode:         "Independent",
 		NTPTimeSettings:     "on, pool.ntp.org, 00:00:00",
+		NTPEnabled:          "on",
+		PowerDraw:           "0.0A,0.0W",
 	}
 
 	if !reflect.DeepEqual(expected, status) {
@@ -296,9 +814,7 @@ func TestDevice_Status(t *testing.T) {
 
 	statusToReturn = 400
 	_, err = device.Status(ctx)
-	if err == nil {
-		t.Errorf("expected an error on status 400, got none")
-	}
+	assertHTTPStatusError(t, err, 400, "status.xml")
 
 	statusToReturn = 200
 	bodyToReturn = `{"some":"json"}`
@@ -306,3 +822,181 @@ func TestDevice_Status(t *testing.T) {
 		t.Errorf("expected an error on a non-XML body, got none")
 	}
 }
+
+func TestDevice_RawStatus(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	statusToReturn := 200
+	device, server := newTestDevice(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/status.xml" {
+			t.Errorf("expected URL /status.xml, got %s", r.URL.Path)
+		}
+		w.WriteHeader(statusToReturn)
+		w.Write([]byte(statusResponse))
+	})
+	defer server.Close()
+
+	body, err := device.RawStatus(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != statusResponse {
+		t.Errorf("expected raw body to equal the server response, got %q", body)
+	}
+
+	statusToReturn = 400
+	_, err = device.RawStatus(ctx)
+	assertHTTPStatusError(t, err, 400, "status.xml")
+}
+
+func TestDevice_GetIntensities(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	device, server := newTestDevice(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/status.xml" {
+			t.Errorf("expected URL /status.xml, got %s", r.URL.Path)
+		}
+		w.Write([]byte(statusResponse))
+	})
+	defer server.Close()
+
+	intensities, err := device.GetIntensities(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []int{0, 0, 0, 0}; !reflect.DeepEqual(expected, intensities) {
+		t.Errorf("expected intensities %v, got %v", expected, intensities)
+	}
+}
+
+func TestDevice_DetectContention(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	device, server := newTestDevice(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(statusResponse))
+	})
+	defer server.Close()
+
+	// statusResponse was last changed by 192.168.1.3 at 2017-03-17 18:58:34.
+	before := time.Date(2017, 3, 17, 18, 0, 0, 0, time.UTC)
+	after := time.Date(2017, 3, 17, 19, 0, 0, 0, time.UTC)
+	tests := []struct {
+		myIP     net.IP
+		since    time.Time
+		expected bool
+	}{
+		{myIP: net.IPv4(192, 168, 1, 50), since: before, expected: true},
+		{myIP: net.IPv4(192, 168, 1, 3), since: before, expected: false},
+		{myIP: net.IPv4(192, 168, 1, 50), since: after, expected: false},
+	}
+	for _, tt := range tests {
+		contention, err := device.DetectContention(ctx, tt.myIP, tt.since)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if contention != tt.expected {
+			t.Errorf("DetectContention(%s, %s): expected %t, got %t", tt.myIP, tt.since, tt.expected, contention)
+		}
+	}
+}
+
+var testWavelengths = WavelengthList{
+	{Number: 0, Wavelength: "450nm", Power: "10.2W"},
+	{Number: 1, Wavelength: "660nm", Power: "5.2W"},
+	{Number: 2, Wavelength: "735nm", Power: "10.0W"},
+	{Number: 3, Wavelength: "5700K", Power: "6.0W"},
+}
+
+func TestWavelengthDescription_Parsed(t *testing.T) {
+	tests := []struct {
+		desc   WavelengthDescription
+		watts  float64
+		nm     int
+		nmOK   bool
+		kelvin int
+		kOK    bool
+	}{
+		{desc: WavelengthDescription{Wavelength: "450nm", Power: "10.2W"}, watts: 10.2, nm: 450, nmOK: true},
+		{desc: WavelengthDescription{Wavelength: "660nm", Power: "5.2W"}, watts: 5.2, nm: 660, nmOK: true},
+		{desc: WavelengthDescription{Wavelength: "5700K", Power: "6.0W"}, watts: 6, kelvin: 5700, kOK: true},
+		{desc: WavelengthDescription{Wavelength: "UV", Power: "1W"}, watts: 1},
+	}
+	for _, tt := range tests {
+		watts, err := tt.desc.PowerWatts()
+		if err != nil {
+			t.Errorf("%+v: unexpected error: %s", tt.desc, err)
+		} else if watts != tt.watts {
+			t.Errorf("%+v: expected %v watts, got %v", tt.desc, tt.watts, watts)
+		}
+		if nm, ok := tt.desc.WavelengthNanometers(); nm != tt.nm || ok != tt.nmOK {
+			t.Errorf("%+v: expected (%d, %t) nanometers, got (%d, %t)", tt.desc, tt.nm, tt.nmOK, nm, ok)
+		}
+		if k, ok := tt.desc.ColorTempKelvin(); k != tt.kelvin || ok != tt.kOK {
+			t.Errorf("%+v: expected (%d, %t) kelvin, got (%d, %t)", tt.desc, tt.kelvin, tt.kOK, k, ok)
+		}
+	}
+
+	for _, power := range []string{"", "5.2", "fiveW", "5.2kW"} {
+		if _, err := (WavelengthDescription{Power: power}).PowerWatts(); err == nil {
+			t.Errorf("PowerWatts(%q): expected an error, got none", power)
+		}
+	}
+}
+
+func TestWavelengthList_ByNumber(t *testing.T) {
+	desc, ok := testWavelengths.ByNumber(1)
+	if !ok {
+		t.Fatal("expected channel 1 to be found")
+	}
+	if expected := (WavelengthDescription{Number: 1, Wavelength: "660nm", Power: "5.2W"}); desc != expected {
+		t.Errorf("expected %+v, got %+v", expected, desc)
+	}
+	if _, ok := testWavelengths.ByNumber(4); ok {
+		t.Errorf("expected channel 4 not to be found")
+	}
+}
+
+func TestWavelengthList_ByWavelength(t *testing.T) {
+	var diag Diagnostic
+	if err := xml.Unmarshal([]byte(diagResponse), &diag); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		label    string
+		expected WavelengthDescription
+	}{
+		{label: "450nm", expected: WavelengthDescription{Number: 0, Wavelength: "450nm", Power: "10.2W"}},
+		{label: "660nm", expected: WavelengthDescription{Number: 1, Wavelength: "660nm", Power: "5.2W"}},
+		{label: "735NM", expected: WavelengthDescription{Number: 2, Wavelength: "735nm", Power: "10.0W"}},
+		{label: "5700K", expected: WavelengthDescription{Number: 3, Wavelength: "5700K", Power: "6.0W"}},
+	}
+	for _, tt := range tests {
+		desc, ok := diag.Wavelengths.ByWavelength(tt.label)
+		if !ok {
+			t.Errorf("expected %q to be found", tt.label)
+			continue
+		}
+		if desc != tt.expected {
+			t.Errorf("%q: expected %+v, got %+v", tt.label, tt.expected, desc)
+		}
+	}
+	if _, ok := diag.Wavelengths.ByWavelength("530nm"); ok {
+		t.Errorf("expected 530nm not to be found")
+	}
+}
+
+func TestWavelengthList_Contains(t *testing.T) {
+	for _, label := range []string{"450nm", "5700K", "5700k"} {
+		if !testWavelengths.Contains(label) {
+			t.Errorf("expected list to contain %q", label)
+		}
+	}
+	for _, label := range []string{"530nm", "", "660"} {
+		if testWavelengths.Contains(label) {
+			t.Errorf("expected list not to contain %q", label)
+		}
+	}
+}
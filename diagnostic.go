@@ -0,0 +1,208 @@
+package heliospectra
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Role is the role a fixture plays in a master/slave group.
+type Role int
+
+const (
+	// RoleIndependent is a fixture that is neither controlled by nor
+	// controlling other fixtures.
+	RoleIndependent Role = iota
+	// RoleMaster is a fixture that broadcasts its light settings to slaves.
+	RoleMaster
+	// RoleSlave is a fixture that follows the light settings of a master.
+	RoleSlave
+)
+
+func (r Role) String() string {
+	switch r {
+	case RoleIndependent:
+		return "Independent"
+	case RoleMaster:
+		return "Master"
+	case RoleSlave:
+		return "Slave"
+	default:
+		return fmt.Sprintf("Role(%d)", int(r))
+	}
+}
+
+// Role returns the fixture's role as reported by MasterOrSlave. For a slave,
+// the MAC address of the master it follows is also returned, taken from the
+// first MAC address listed in Masters.
+func (d *Diagnostic) Role() (Role, net.HardwareAddr, error) {
+	switch strings.ToLower(strings.TrimSpace(d.MasterOrSlave)) {
+	case "independent":
+		return RoleIndependent, nil, nil
+	case "master":
+		return RoleMaster, nil, nil
+	case "slave":
+	default:
+		return 0, nil, fmt.Errorf("unknown masterOrSlave value %q", d.MasterOrSlave)
+	}
+
+	fields := strings.FieldsFunc(d.Masters, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+	for _, field := range fields {
+		if mac, err := net.ParseMAC(field); err == nil {
+			return RoleSlave, mac, nil
+		}
+	}
+	return 0, nil, fmt.Errorf("no master MAC address found in masters %q", d.Masters)
+}
+
+// ActiveIP returns the address of the fixture's active network interface: its
+// Ethernet address if it has one, otherwise its WLAN address. Unset and
+// unspecified (0.0.0.0) addresses are ignored. It returns nil if neither
+// interface has an address.
+func (d *Diagnostic) ActiveIP() net.IP {
+	for _, ip := range []net.IP{d.EthernetIP, d.WLANIP} {
+		if ip != nil && !ip.IsUnspecified() {
+			return ip
+		}
+	}
+	return nil
+}
+
+// ChannelCount returns the number of wavelength channels on the fixture.
+func (d *Diagnostic) ChannelCount() int {
+	return len(d.Wavelengths)
+}
+
+// ParsedIntensities parses the diagnostic's Intensities field into a slice of
+// intensities ordered by wavelength number.
+func (d *Diagnostic) ParsedIntensities() ([]int, error) {
+	return parseIntensities(d.Intensities)
+}
+
+// ValidateChannelCount returns an error if the number of intensities in the
+// diagnostic doesn't match the number of wavelength channels.
+func (d *Diagnostic) ValidateChannelCount() error {
+	intensities, err := d.ParsedIntensities()
+	if err != nil {
+		return err
+	}
+	if len(intensities) != d.ChannelCount() {
+		return fmt.Errorf("diagnostic has %d intensities but %d wavelengths", len(intensities), d.ChannelCount())
+	}
+	return nil
+}
+
+// DisplayName returns the name to show for the fixture: its Title if it has been
+// given one, otherwise its Model. Unnamed fixtures report their model as the
+// title, so a Title equal to Model is treated as unset.
+func (d *Diagnostic) DisplayName() string {
+	title := strings.TrimSpace(d.Title)
+	if title == "" || title == d.Model {
+		return d.Model
+	}
+	return title
+}
+
+// Branding is the branding a fixture displays in its web interface.
+type Branding struct {
+	Link    string
+	Text    string
+	Favicon string
+}
+
+// Branding returns the fixture's branding fields.
+func (d *Diagnostic) Branding() Branding {
+	return Branding{
+		Link:    d.PoweredLink,
+		Text:    d.PoweredText,
+		Favicon: d.Favicon,
+	}
+}
+
+// rebootSlack is how far a fixture's runtime may lag behind its clock before a
+// reboot is assumed, to allow for the clock being adjusted.
+const rebootSlack = time.Minute
+
+// LooksRebootedSince reports whether the fixture appears to have rebooted
+// between prev and this diagnostic being fetched. It does so if the runtime
+// went backwards, or if the runtime advanced by less than the fixture's clock
+// did. Callers that cache diagnostics can use this to invalidate them. It
+// returns false if prev is nil, as on a cache's first fetch, or if the
+// runtimes can't be parsed.
+func (d *Diagnostic) LooksRebootedSince(prev *Diagnostic) bool {
+	if prev == nil {
+		return false
+	}
+	runtime, err := ParseUptime(d.Runtime)
+	if err != nil {
+		return false
+	}
+	prevRuntime, err := ParseUptime(prev.Runtime)
+	if err != nil {
+		return false
+	}
+	if runtime < prevRuntime {
+		return true
+	}
+
+	clock, err := ParseDeviceClock(d.Clock, time.UTC)
+	if err != nil {
+		return false
+	}
+	prevClock, err := ParseDeviceClock(prev.Clock, time.UTC)
+	if err != nil {
+		return false
+	}
+	elapsed := clock.Sub(prevClock)
+	return runtime-prevRuntime < elapsed-rebootSlack
+}
+
+// IndexedField parses the diagnostic field with the given XML element name,
+// such as "temps" or "intensities", as a list of index:value pairs keyed by
+// index. Any string field can be parsed this way, so an indexed field added by
+// newer firmware only needs a Diagnostic field rather than its own parser.
+func (d *Diagnostic) IndexedField(name string) (map[int]string, error) {
+	v := reflect.ValueOf(d).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if strings.Split(t.Field(i).Tag.Get("xml"), ",")[0] != name {
+			continue
+		}
+		s, ok := v.Field(i).Interface().(string)
+		if !ok {
+			return nil, fmt.Errorf("diagnostic field %q isn't a string", name)
+		}
+		values, err := splitIndexed(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid diagnostic field %q: %w", name, err)
+		}
+		fields := make(map[int]string, len(values))
+		for idx, value := range values {
+			fields[idx] = value
+		}
+		return fields, nil
+	}
+	return nil, fmt.Errorf("unknown diagnostic field %q", name)
+}
+
+// NTPOffsetDuration parses the diagnostic's NTPOffset, the fixed offset the
+// fixture applies to NTP time, such as "01:00:00".
+func (d *Diagnostic) NTPOffsetDuration() (time.Duration, error) {
+	return parseNTPOffset(d.NTPOffset)
+}
+
+// ParsedRuntime parses the diagnostic's Runtime, the time since the fixture
+// last started.
+func (d *Diagnostic) ParsedRuntime() (time.Duration, error) {
+	return ParseUptime(d.Runtime)
+}
+
+// ParsedClock parses the diagnostic's Clock in the device's time zone loc, or
+// in time.Local if loc is nil.
+func (d *Diagnostic) ParsedClock(loc *time.Location) (time.Time, error) {
+	return ParseDeviceClock(d.Clock, loc)
+}
@@ -0,0 +1,244 @@
+package heliospectra
+
+import (
+	"encoding/xml"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiagnostic_Role(t *testing.T) {
+	masterMAC, err := net.ParseMAC("64:1a:00:00:00:01")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		masterOrSlave, masters string
+		role                   Role
+		mac                    net.HardwareAddr
+	}{
+		{masterOrSlave: "Independent", masters: " ", role: RoleIndependent},
+		{masterOrSlave: "Master", masters: " ", role: RoleMaster},
+		{masterOrSlave: "Slave", masters: "64:1a:00:00:00:01", role: RoleSlave, mac: masterMAC},
+		{masterOrSlave: "slave", masters: " 64:1A:00:00:00:01, ", role: RoleSlave, mac: masterMAC},
+	}
+	for _, tt := range tests {
+		diag := &Diagnostic{MasterOrSlave: tt.masterOrSlave, Masters: tt.masters}
+		role, mac, err := diag.Role()
+		if err != nil {
+			t.Errorf("Role() for %q: unexpected error: %s", tt.masterOrSlave, err)
+			continue
+		}
+		if role != tt.role {
+			t.Errorf("Role() for %q: expected role %s, got %s", tt.masterOrSlave, tt.role, role)
+		}
+		if !reflect.DeepEqual(mac, tt.mac) {
+			t.Errorf("Role() for %q: expected master %s, got %s", tt.masterOrSlave, tt.mac, mac)
+		}
+	}
+
+	for _, diag := range []*Diagnostic{
+		{MasterOrSlave: "Slave", Masters: " "},
+		{MasterOrSlave: "Unknown"},
+	} {
+		if _, _, err := diag.Role(); err == nil {
+			t.Errorf("Role() for %+v: expected an error, got none", diag)
+		}
+	}
+}
+
+func TestDiagnostic_ActiveIP(t *testing.T) {
+	ethernet := net.IPv4(192, 168, 1, 8)
+	wlan := net.IPv4(192, 168, 2, 8)
+	tests := []struct {
+		name       string
+		ethernetIP net.IP
+		wlanIP     net.IP
+		expected   net.IP
+	}{
+		{name: "ethernet only", ethernetIP: ethernet, expected: ethernet},
+		{name: "wlan only", wlanIP: wlan, expected: wlan},
+		{name: "both", ethernetIP: ethernet, wlanIP: wlan, expected: ethernet},
+		{name: "unspecified ethernet", ethernetIP: net.IPv4zero, wlanIP: wlan, expected: wlan},
+		{name: "neither", expected: nil},
+	}
+	for _, tt := range tests {
+		diag := &Diagnostic{EthernetIP: tt.ethernetIP, WLANIP: tt.wlanIP}
+		if got := diag.ActiveIP(); !tt.expected.Equal(got) || (tt.expected == nil) != (got == nil) {
+			t.Errorf("%s: expected ActiveIP()=%s, got %s", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestDiagnostic_NTPOffsetDuration(t *testing.T) {
+	diag := &Diagnostic{}
+	if err := xml.Unmarshal([]byte(diagResponse), diag); err != nil {
+		t.Fatal(err)
+	}
+	offset, err := diag.NTPOffsetDuration()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if offset != 0 {
+		t.Errorf("expected a zero offset from the fixture, got %s", offset)
+	}
+}
+
+func TestDiagnostic_ParsedRuntime(t *testing.T) {
+	diag := &Diagnostic{Runtime: "0d 02h 10m 08s"}
+	runtime, err := diag.ParsedRuntime()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := 2*time.Hour + 10*time.Minute + 8*time.Second; runtime != expected {
+		t.Errorf("expected runtime %s, got %s", expected, runtime)
+	}
+	diag.Runtime = "forever"
+	if _, err := diag.ParsedRuntime(); err == nil {
+		t.Errorf("expected an error for a malformed runtime, got none")
+	}
+}
+
+func TestDiagnostic_ChannelCount(t *testing.T) {
+	diag := &Diagnostic{
+		Wavelengths: WavelengthList{
+			{Number: 0, Wavelength: "450nm", Power: "10.2W"},
+			{Number: 1, Wavelength: "660nm", Power: "5.2W"},
+			{Number: 2, Wavelength: "735nm", Power: "10.0W"},
+			{Number: 3, Wavelength: "5700K", Power: "6.0W"},
+		},
+		Intensities: "0:0,1:0,2:0,3:0,",
+	}
+	if count := diag.ChannelCount(); count != 4 {
+		t.Errorf("expected ChannelCount=4, got %d", count)
+	}
+	if err := diag.ValidateChannelCount(); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	diag.Intensities = "0:0,1:0,2:0,"
+	if err := diag.ValidateChannelCount(); err == nil {
+		t.Errorf("expected an error when intensities and wavelengths differ, got none")
+	}
+}
+
+func TestDiagnostic_ParsedIntensities(t *testing.T) {
+	diag := &Diagnostic{Intensities: "0:100,1:200,2:0,3:1000,"}
+	got, err := diag.ParsedIntensities()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []int{100, 200, 0, 1000}; !reflect.DeepEqual(expected, got) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	diag.Intensities = "0:100,2:200,"
+	if _, err := diag.ParsedIntensities(); err == nil {
+		t.Errorf("expected an error for non-contiguous indexes, got none")
+	}
+}
+
+func TestDiagnostic_DisplayName(t *testing.T) {
+	tests := []struct {
+		model, title, expected string
+	}{
+		{model: "L4", title: "L4", expected: "L4"},
+		{model: "L4", title: "", expected: "L4"},
+		{model: "L4", title: " ", expected: "L4"},
+		{model: "L4", title: "Bench 3 North", expected: "Bench 3 North"},
+	}
+	for _, tt := range tests {
+		diag := &Diagnostic{Model: tt.model, Title: tt.title}
+		if got := diag.DisplayName(); got != tt.expected {
+			t.Errorf("DisplayName() with title %q: expected %q, got %q", tt.title, tt.expected, got)
+		}
+	}
+}
+
+func TestDiagnostic_Branding(t *testing.T) {
+	diag := &Diagnostic{}
+	if err := xml.Unmarshal([]byte(diagResponse), diag); err != nil {
+		t.Fatal(err)
+	}
+	expected := Branding{
+		Link:    "http://www.heliospectra.com",
+		Text:    "Powered by Heliospectra",
+		Favicon: "/favi.ico",
+	}
+	if got := diag.Branding(); got != expected {
+		t.Errorf("expected branding=%+v, got %+v", expected, got)
+	}
+}
+
+func TestDiagnostic_LooksRebootedSince(t *testing.T) {
+	prev := &Diagnostic{Clock: "2017:03:17:02:48:41", Runtime: "0d 02h 10m 08s"}
+
+	tests := []struct {
+		name     string
+		diag     *Diagnostic
+		expected bool
+	}{
+		{
+			name:     "still running",
+			diag:     &Diagnostic{Clock: "2017:03:17:03:48:41", Runtime: "0d 03h 10m 08s"},
+			expected: false,
+		},
+		{
+			name:     "runtime reset",
+			diag:     &Diagnostic{Clock: "2017:03:17:03:48:41", Runtime: "0d 00h 05m 00s"},
+			expected: true,
+		},
+		{
+			name:     "runtime behind clock",
+			diag:     &Diagnostic{Clock: "2017:03:18:02:48:41", Runtime: "0d 03h 10m 08s"},
+			expected: true,
+		},
+		{
+			name:     "unparseable runtime",
+			diag:     &Diagnostic{Clock: "2017:03:17:03:48:41", Runtime: "unknown"},
+			expected: false,
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.diag.LooksRebootedSince(prev); got != tt.expected {
+			t.Errorf("%s: expected LooksRebootedSince=%t, got %t", tt.name, tt.expected, got)
+		}
+	}
+
+	if tests[0].diag.LooksRebootedSince(nil) {
+		t.Errorf("expected LooksRebootedSince(nil)=false")
+	}
+}
+
+func TestDiagnostic_IndexedField(t *testing.T) {
+	var diag Diagnostic
+	if err := xml.Unmarshal([]byte(diagResponse), &diag); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		expected map[int]string
+	}{
+		{name: "temps", expected: map[int]string{0: "26.8C"}},
+		{name: "intensities", expected: map[int]string{0: "0", 1: "0", 2: "0", 3: "0"}},
+	}
+	for _, tt := range tests {
+		got, err := diag.IndexedField(tt.name)
+		if err != nil {
+			t.Errorf("IndexedField(%q): unexpected error: %s", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(tt.expected, got) {
+			t.Errorf("IndexedField(%q): expected %v, got %v", tt.name, tt.expected, got)
+		}
+	}
+
+	// unknown fields, non-string fields, and fields that aren't indexed
+	for _, name := range []string{"nope", "networkIP", "wavelengths", "model"} {
+		if _, err := diag.IndexedField(name); err == nil {
+			t.Errorf("IndexedField(%q): expected an error, got none", name)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package heliospectra
+
+import "strings"
+
+// Default paths of the HTTP endpoints on a Device.
+const (
+	DefaultDiagnosticPath = "diag.xml"
+	DefaultStatusPath     = "status.xml"
+	DefaultIntensityPath  = "intensity.cgi"
+)
+
+// Endpoints overrides the paths of a Device's HTTP endpoints, for firmware
+// revisions that serve them under a different name or casing, such as
+// "Intensity.cgi". Empty paths use the defaults. A leading slash is optional.
+type Endpoints struct {
+	Diagnostic string
+	Status     string
+	Intensity  string
+}
+
+func (e Endpoints) diagnostic() string { return endpointPath(e.Diagnostic, DefaultDiagnosticPath) }
+func (e Endpoints) status() string     { return endpointPath(e.Status, DefaultStatusPath) }
+func (e Endpoints) intensity() string  { return endpointPath(e.Intensity, DefaultIntensityPath) }
+
+func endpointPath(path, def string) string {
+	path = strings.TrimLeft(path, "/")
+	if path == "" {
+		return def
+	}
+	return path
+}
@@ -0,0 +1,49 @@
+package heliospectra
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDevice_Endpoints(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var gotQuery string
+	device, server := newTestDevice(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/Intensity.cgi":
+			gotQuery = r.URL.Query().Get("int")
+		case "/Status.xml":
+			w.Write([]byte(statusResponse))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer server.Close()
+
+	if err := device.SetIntensities(ctx, 1, 2, 3, 4); err == nil {
+		t.Errorf("expected an error from the default intensity path, got none")
+	}
+
+	device.Endpoints = Endpoints{Intensity: "Intensity.cgi", Status: "/Status.xml"}
+	if err := device.SetIntensities(ctx, 1, 2, 3, 4); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "1:2:3:4"; gotQuery != expected {
+		t.Errorf("expected int=%s, got %s", expected, gotQuery)
+	}
+	intensities, err := device.GetIntensities(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []int{0, 0, 0, 0}; !reflect.DeepEqual(expected, intensities) {
+		t.Errorf("expected intensities %v, got %v", expected, intensities)
+	}
+	if _, err := device.Diagnostic(ctx); err == nil {
+		t.Errorf("expected an error from the default diagnostic path, got none")
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"encoding/xml"
 	"fmt"
 	"net"
+	"strings"
+	"syscall"
 	"time"
 )
 
@@ -64,89 +66,602 @@ type DeviceInfo struct {
 	SerialNum string `xml:"SerialNr"`
 }
 
-var broadcastIPV4 = net.IPv4(255, 255, 255, 255)
+// NormalizedMAC returns the device's MAC address in lowercase, colon-separated
+// form, such as "64:1a:10:10:10:10", so that it can be compared with MAC
+// addresses from other sources. If MAC can't be parsed, it's returned
+// lowercased.
+func (di DeviceInfo) NormalizedMAC() string {
+	mac, err := net.ParseMAC(strings.TrimSpace(di.MAC))
+	if err != nil {
+		return strings.ToLower(di.MAC)
+	}
+	return mac.String()
+}
+
+const (
+	// DefaultScanBufferSize is the default size of the buffer used to receive
+	// each scan reply.
+	DefaultScanBufferSize = 4096
+	// MaxScanBufferSize is the largest scan reply that can be received, which
+	// is the maximum payload of a UDP datagram over IPv4.
+	MaxScanBufferSize = 65507
+	// DefaultScanResultsCapacity is the default initial capacity of the scan
+	// results slice.
+	DefaultScanResultsCapacity = 64
+	// DefaultScanTimeout is how long a scan waits for replies by default.
+	DefaultScanTimeout = 4 * time.Second
+)
 
-// ScanUDP performs a UDP device scan. The scan ends when the ctx is closed or
-// after 4 seconds.
+// ScanOptions configures a UDP device scan. The zero value uses the defaults.
+type ScanOptions struct {
+	// BufferSize is the size of the buffer used to receive each scan reply,
+	// up to MaxScanBufferSize. Replies larger than the buffer are discarded
+	// rather than being truncated. Defaults to DefaultScanBufferSize.
+	BufferSize int
+	// ResultsCapacity is the initial capacity of the returned slice, which
+	// avoids reallocations in rooms with many fixtures. Defaults to
+	// DefaultScanResultsCapacity.
+	ResultsCapacity int
+	// OnError, if set, is called with each reply that can't be decoded. The
+	// scan continues regardless.
+	OnError func(error)
+	// Interface, if set, restricts the scan to the network interface with
+	// this name, such as "eth0".
+	Interface string
+	// Timeout is how long the scan waits for replies. Defaults to
+	// DefaultScanTimeout.
+	Timeout time.Duration
+	// ExpectedDevices, if non-zero, ends the scan as soon as replies from this
+	// many distinct devices have been received, rather than waiting for the
+	// Timeout.
+	ExpectedDevices int
+}
+
+func (o ScanOptions) withDefaults() (ScanOptions, error) {
+	if o.BufferSize == 0 {
+		o.BufferSize = DefaultScanBufferSize
+	}
+	if o.BufferSize < 0 || o.BufferSize > MaxScanBufferSize {
+		return o, fmt.Errorf("scan buffer size %d out of range [1,%d]", o.BufferSize, MaxScanBufferSize)
+	}
+	if o.ResultsCapacity == 0 {
+		o.ResultsCapacity = DefaultScanResultsCapacity
+	}
+	if o.ResultsCapacity < 0 {
+		return o, fmt.Errorf("scan results capacity %d must not be negative", o.ResultsCapacity)
+	}
+	if o.Timeout == 0 {
+		o.Timeout = DefaultScanTimeout
+	}
+	if o.Timeout < 0 {
+		return o, fmt.Errorf("scan timeout %s must not be negative", o.Timeout)
+	}
+	if o.ExpectedDevices < 0 {
+		return o, fmt.Errorf("expected devices %d must not be negative", o.ExpectedDevices)
+	}
+	return o, nil
+}
+
+// ScanUDP performs a UDP device scan using the default ScanOptions. The scan
+// ends when the ctx is closed or after DefaultScanTimeout.
 func ScanUDP(ctx context.Context) ([]DeviceInfo, error) {
-	ctx, cancel := context.WithTimeout(ctx, 4*time.Second)
+	return ScanUDPWithOptions(ctx, ScanOptions{})
+}
+
+// ScanUDPWithOptions performs a UDP device scan configured by opts. The scan
+// ends when the ctx is closed, after opts.Timeout, or once
+// opts.ExpectedDevices have replied.
+func ScanUDPWithOptions(ctx context.Context, opts ScanOptions) ([]DeviceInfo, error) {
+	return scanUDP(ctx, commandIDQuery, opts)
+}
+
+// ScanUnmutedUDP is like ScanUDP, but only devices that haven't been muted
+// with MuteDevice reply. This narrows a scan to a chosen group of devices.
+func ScanUnmutedUDP(ctx context.Context) ([]DeviceInfo, error) {
+	return scanUDP(ctx, commandIDQueryUnmuted, ScanOptions{})
+}
+
+// MuteDevice excludes the device with the given MAC address from
+// ScanUnmutedUDP scans until it's unmuted. It doesn't affect ScanUDP.
+func MuteDevice(ctx context.Context, mac net.HardwareAddr) error {
+	return broadcastCommand(ctx, commandIDMute, mac)
+}
+
+// UnmuteDevice includes the device with the given MAC address in
+// ScanUnmutedUDP scans again after MuteDevice.
+func UnmuteDevice(ctx context.Context, mac net.HardwareAddr) error {
+	return broadcastCommand(ctx, commandIDUnmute, mac)
+}
+
+// broadcastCommand broadcasts a command addressed to the device with the given
+// MAC address, without waiting for a reply. Like a scan, it's sent to every
+// subnet, so that it reaches the device wherever a scan would find it.
+func broadcastCommand(ctx context.Context, cmd commandID, mac net.HardwareAddr) error {
+	payload, err := makeUDPPayload(cmd, mac, nil)
+	if err != nil {
+		return err
+	}
+	targets, err := broadcastAddrs(udpConfigFrom(ctx), "")
+	if err != nil {
+		return err
+	}
+	return udpSendAll(ctx, targets, payload)
+}
+
+func scanUDP(ctx context.Context, cmd commandID, opts ScanOptions) ([]DeviceInfo, error) {
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
 	defer cancel()
 
-	socket, err := net.DialUDP("udp4", nil, &net.UDPAddr{
-		IP:   broadcastIPV4,
-		Port: UDPPort,
-	})
+	payload, err := makeUDPPayloadShort(cmd)
 	if err != nil {
 		return nil, err
 	}
-	defer socket.Close()
 
-	recvSocket, err := net.ListenUDP("udp4", &net.UDPAddr{
-		IP:   net.IPv4(0, 0, 0, 0),
-		Port: UDPPort,
+	targets, err := broadcastAddrs(udpConfigFrom(ctx), opts.Interface)
+	if err != nil {
+		return nil, err
+	}
+
+	resultSerials := make(map[string]bool)
+	results := make([]DeviceInfo, 0, opts.ResultsCapacity)
+	err = udpExchange(ctx, payload, targets, opts, func(di DeviceInfo) bool {
+		if !resultSerials[di.SerialNum] {
+			resultSerials[di.SerialNum] = true
+			results = append(results, di)
+		}
+		return opts.ExpectedDevices == 0 || len(results) < opts.ExpectedDevices
 	})
 	if err != nil {
 		return nil, err
 	}
-	defer recvSocket.Close()
+	return results, nil
+}
 
-	ch := make(chan DeviceInfo)
-	go udpScanReceive(ctx, recvSocket, ch)
+// NetworkConfig is the network configuration of a device.
+type NetworkConfig struct {
+	DHCP    bool
+	IPAddr  net.IP
+	NetMask string
+	Gateway net.IP
+	DNS1    net.IP
+	DNS2    net.IP
+}
+
+// NetworkConfig returns the network configuration reported in the scan reply.
+func (di DeviceInfo) NetworkConfig() NetworkConfig {
+	return NetworkConfig{
+		DHCP:    di.DHCP,
+		IPAddr:  di.IPAddr,
+		NetMask: di.NetMask,
+		Gateway: di.Gateway,
+		DNS1:    di.DNS1,
+		DNS2:    di.DNS2,
+	}
+}
+
+// GetNetworkConfigUDP reads the network configuration of the Device over UDP,
+// without using its HTTP server. The query is sent to the Device's address and
+// addressed to the device with the given MAC address, or to the Device's MAC
+// address if mac is nil, so that only that device replies. It waits for a
+// reply until ctx is done or for at most DefaultScanTimeout.
+func (d *Device) GetNetworkConfigUDP(ctx context.Context, mac net.HardwareAddr) (*NetworkConfig, error) {
+	if mac == nil {
+		mac = d.mac
+	}
+	if mac == nil {
+		return nil, fmt.Errorf("unable to query device %s: MAC address unknown", d.addr)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, DefaultScanTimeout)
+	defer cancel()
 
-	payload, err := makeUDPPayloadShort(commandIDQuery)
+	payload, err := makeUDPPayload(commandIDQuery, mac, nil)
 	if err != nil {
 		return nil, err
 	}
-	if _, err = socket.Write(payload); err != nil {
+
+	var config *NetworkConfig
+	err = udpExchange(ctx, payload, []net.IP{d.addr}, ScanOptions{BufferSize: DefaultScanBufferSize}, func(di DeviceInfo) bool {
+		if replyMAC, err := net.ParseMAC(di.MAC); err == nil && bytes.Equal(replyMAC, mac) {
+			nc := di.NetworkConfig()
+			config = &nc
+			return false
+		}
+		return true
+	})
+	if err != nil {
 		return nil, err
 	}
+	if config == nil {
+		return nil, fmt.Errorf("no reply from device %s", mac)
+	}
+	return config, nil
+}
+
+// Restart restarts the Device by sending it a restart command over UDP. The
+// Device's MAC address must be known, see SetMAC. No reply is expected, so a
+// nil error only means that the command was sent.
+func (d *Device) Restart(ctx context.Context) error {
+	if d.mac == nil {
+		return fmt.Errorf("unable to restart device %s: MAC address unknown", d.addr)
+	}
+	payload, err := makeUDPPayload(commandIDRestart, d.mac, nil)
+	if err != nil {
+		return err
+	}
+	return udpSend(ctx, d.addr, payload)
+}
+
+// udpSend sends payload to the device at addr, which may be the broadcast
+// address, without waiting for a reply.
+func udpSend(ctx context.Context, addr net.IP, payload []byte) error {
+	cfg := udpConfigFrom(ctx)
+	dialer := net.Dialer{Control: broadcastControl(cfg.setBroadcastSockopt)}
+	conn, err := dialer.DialContext(ctx, "udp4", (&net.UDPAddr{
+		IP:   addr,
+		Port: cfg.devicePort,
+	}).String())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetWriteDeadline(deadline); err != nil {
+			return err
+		}
+	}
+	_, err = conn.Write(payload)
+	return err
+}
+
+// udpSendAll sends payload to every target, and only fails if it couldn't be
+// sent to any of them.
+func udpSendAll(ctx context.Context, targets []net.IP, payload []byte) error {
+	var sendErr error
+	sent := 0
+	for _, target := range targets {
+		if err := udpSend(ctx, target, payload); err != nil {
+			if sendErr == nil {
+				sendErr = fmt.Errorf("unable to send to %s: %w", target, err)
+			}
+			continue
+		}
+		sent++
+	}
+	if sent == 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return sendErr
+	}
+	return nil
+}
+
+// udpConfig is how the package talks to devices over UDP. Tests point it at
+// mock devices by passing their own udpConfig in the context with
+// withUDPConfig, rather than by replacing package state that a scan still
+// running in the background could be reading.
+type udpConfig struct {
+	// broadcastIP is the limited broadcast address, used when there are no
+	// directed broadcast addresses to send a query to.
+	broadcastIP net.IP
+	// devicePort is the port devices receive commands on and send replies
+	// from.
+	devicePort int
+	// listenPort is the local port that replies are received on.
+	listenPort int
+	// listInterfaces lists the host's network interfaces.
+	listInterfaces func() ([]localInterface, error)
+	// listenUDP opens the socket that replies are received on, giving up when
+	// ctx is done.
+	listenUDP func(ctx context.Context, network string, laddr *net.UDPAddr) (*net.UDPConn, error)
+	// setBroadcastSockopt enables SO_BROADCAST on a socket.
+	setBroadcastSockopt func(fd uintptr) error
+}
+
+// defaultUDPConfig returns the udpConfig used to talk to real devices.
+func defaultUDPConfig() *udpConfig {
+	return &udpConfig{
+		broadcastIP:         net.IPv4(255, 255, 255, 255),
+		devicePort:          UDPPort,
+		listenPort:          UDPPort,
+		listInterfaces:      listInterfaces,
+		listenUDP:           listenUDP,
+		setBroadcastSockopt: setBroadcast,
+	}
+}
+
+type udpConfigKey struct{}
+
+// withUDPConfig returns a copy of ctx carrying cfg, which UDP commands sent
+// with it use instead of defaultUDPConfig.
+func withUDPConfig(ctx context.Context, cfg *udpConfig) context.Context {
+	return context.WithValue(ctx, udpConfigKey{}, cfg)
+}
+
+// udpConfigFrom returns the udpConfig added to ctx with withUDPConfig, or
+// defaultUDPConfig if there isn't one.
+func udpConfigFrom(ctx context.Context) *udpConfig {
+	if cfg, ok := ctx.Value(udpConfigKey{}).(*udpConfig); ok {
+		return cfg
+	}
+	return defaultUDPConfig()
+}
+
+// BindError is returned when the UDP port that devices send their replies to
+// can't be bound, usually because another process (such as another scanner)
+// is using it or because binding it requires elevated privileges. Devices
+// always reply to UDPPort, so replies can't be received on any other port.
+type BindError struct {
+	Port int
+	Err  error
+}
+
+func (e *BindError) Error() string {
+	return fmt.Sprintf("unable to listen for device replies on UDP port %d, which may be in use or require privileges: %s", e.Port, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *BindError) Unwrap() error {
+	return e.Err
+}
+
+// udpExchange sends payload to each of targets and calls handle with each info
+// reply received until ctx is done or handle returns false. It returns an error
+// if the query can't be sent or replies stop being received before then.
+func udpExchange(ctx context.Context, payload []byte, targets []net.IP, opts ScanOptions, handle func(DeviceInfo) bool) error {
+	// Setup honors ctx too, so that a cancelled scan returns ctx's error
+	// rather than a socket error, or nothing at all.
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	cfg := udpConfigFrom(ctx)
+	recvSocket, err := cfg.listenUDP(ctx, "udp4", &net.UDPAddr{
+		IP:   net.IPv4(0, 0, 0, 0),
+		Port: cfg.listenPort,
+	})
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return &BindError{Port: cfg.listenPort, Err: err}
+	}
+
+	ch := make(chan DeviceInfo)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- udpScanReceive(ctx, recvSocket, cfg.devicePort, ch, opts.BufferSize, opts.OnError)
+		close(errCh)
+	}()
+	// Don't return until the receiver has stopped, so that nothing is still
+	// reading from the socket, or calling OnError, after the exchange ends.
+	// Once the receiver's error has been taken below, errCh is closed and
+	// this doesn't block.
+	defer func() {
+		cancel()
+		recvSocket.Close()
+		<-errCh
+	}()
+
+	if err := udpSendAll(ctx, targets, payload); err != nil {
+		return err
+	}
 
-	resultSerials := make(map[string]bool)
-	results := make([]DeviceInfo, 0, 64)
 	for {
 		select {
 		case di := <-ch:
-			if !resultSerials[di.SerialNum] {
-				resultSerials[di.SerialNum] = true
-				results = append(results, di)
+			if !handle(di) {
+				return nil
+			}
+		case err := <-errCh:
+			if err != nil && ctx.Err() == nil {
+				return fmt.Errorf("unable to receive replies: %w", err)
 			}
+			return nil
 		case <-ctx.Done():
-			return results, nil
+			return nil
+		}
+	}
+}
+
+// localInterface is a network interface and its addresses.
+type localInterface struct {
+	Name  string
+	Flags net.Flags
+	Addrs []net.Addr
+}
+
+// listInterfaces lists the host's network interfaces.
+func listInterfaces() ([]localInterface, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]localInterface, 0, len(ifaces))
+	for _, iface := range ifaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, localInterface{Name: iface.Name, Flags: iface.Flags, Addrs: addrs})
+	}
+	return result, nil
+}
+
+// broadcastAddrs returns the addresses to broadcast a query to: the directed
+// broadcast address of each IPv4 subnet on the host's up, broadcast-capable
+// interfaces. A host with several interfaces often only sends the limited
+// broadcast address, 255.255.255.255, out of one of them, so fixtures on the
+// other subnets would be missed. If there are no such subnets, the limited
+// broadcast address is used. If name is set, only the interface with that name
+// is used, and it's an error if it has no such subnets, since the limited
+// broadcast address could leave through any interface.
+func broadcastAddrs(cfg *udpConfig, name string) ([]net.IP, error) {
+	ifaces, err := cfg.listInterfaces()
+	if err != nil {
+		if name != "" {
+			return nil, err
+		}
+		return []net.IP{cfg.broadcastIP}, nil
+	}
+
+	var addrs []net.IP
+	found := false
+	for _, iface := range ifaces {
+		if name != "" && iface.Name != name {
+			continue
+		}
+		found = true
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagBroadcast == 0 {
+			continue
+		}
+		for _, addr := range iface.Addrs {
+			if bcast := directedBroadcast(addr); bcast != nil && !containsIP(addrs, bcast) {
+				addrs = append(addrs, bcast)
+			}
+		}
+	}
+	if name != "" && !found {
+		return nil, fmt.Errorf("no interface named %q", name)
+	}
+	if name != "" && len(addrs) == 0 {
+		return nil, fmt.Errorf("interface %q isn't up with an IPv4 broadcast address", name)
+	}
+	if len(addrs) == 0 {
+		return []net.IP{cfg.broadcastIP}, nil
+	}
+	return addrs, nil
+}
+
+// directedBroadcast returns the broadcast address of addr's subnet, or nil if
+// it isn't an IPv4 subnet.
+func directedBroadcast(addr net.Addr) net.IP {
+	ipnet, ok := addr.(*net.IPNet)
+	if !ok {
+		return nil
+	}
+	ip := ipnet.IP.To4()
+	mask := ipnet.Mask
+	if len(mask) == net.IPv6len {
+		mask = mask[12:]
+	}
+	if ip == nil || len(mask) != net.IPv4len {
+		return nil
+	}
+	bcast := make(net.IP, net.IPv4len)
+	for i := range ip {
+		bcast[i] = ip[i] | ^mask[i]
+	}
+	return bcast
+}
+
+func containsIP(ips []net.IP, ip net.IP) bool {
+	for _, other := range ips {
+		if other.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// listenUDP opens the socket that replies are received on, giving up when ctx
+// is done.
+func listenUDP(ctx context.Context, network string, laddr *net.UDPAddr) (*net.UDPConn, error) {
+	var lc net.ListenConfig
+	conn, err := lc.ListenPacket(ctx, network, laddr.String())
+	if err != nil {
+		return nil, err
+	}
+	return conn.(*net.UDPConn), nil
+}
+
+// broadcastControl returns a net.Dialer Control function that explicitly
+// enables broadcasting on the scan socket with setSockopt. Some platforms
+// otherwise silently drop, or fail with an obscure error, writes to the
+// broadcast address.
+func broadcastControl(setSockopt func(fd uintptr) error) func(network, address string, c syscall.RawConn) error {
+	return func(network, address string, c syscall.RawConn) error {
+		var sockErr error
+		if err := c.Control(func(fd uintptr) {
+			sockErr = setSockopt(fd)
+		}); err != nil {
+			return err
 		}
+		if sockErr != nil {
+			return fmt.Errorf("unable to enable broadcast on scan socket: %w", sockErr)
+		}
+		return nil
 	}
 }
 
-func udpScanReceive(ctx context.Context, conn *net.UDPConn, ch chan<- DeviceInfo) {
-	data := make([]byte, 4096)
+// udpScanReceive reads replies sent from devicePort to conn and sends the info
+// replies on ch until ctx is done or a read fails, returning the read's error.
+// Replies that can't be decoded are passed to onError, if it's set, and
+// otherwise ignored.
+func udpScanReceive(ctx context.Context, conn *net.UDPConn, devicePort int, ch chan<- DeviceInfo, bufSize int, onError func(error)) error {
+	// Allocate one extra byte so that a reply which fills it can be detected
+	// as too large for bufSize, since oversized datagrams are silently
+	// truncated by the read.
+	data := make([]byte, bufSize+1)
 	for {
 		read, remoteAddr, err := conn.ReadFromUDP(data)
 		if err != nil {
-			return
+			return err
 		}
-		if remoteAddr.Port != UDPPort {
+		if remoteAddr.Port != devicePort {
 			continue
 		}
-		if read < 17 {
-			continue // invalid, scan results must be > 17 chars
-		}
-		cmdID := commandID(data[12])
-		if cmdID != commandIDInfoReply {
-			continue // we only care about scan responses
+		if read > bufSize {
+			continue // reply too large for the buffer, would be truncated
 		}
-		xmldata := data[16:read]
 		di := DeviceInfo{}
-		if err := xml.Unmarshal(xmldata, &di); err != nil {
-			fmt.Printf("error unmarshaling scan response: %#v\n", err)
+		ok, err := parseInfoReply(data[:read], &di)
+		if err != nil {
+			if onError != nil {
+				onError(fmt.Errorf("unable to decode reply from %s: %w", remoteAddr, err))
+			}
 			continue
 		}
+		if !ok {
+			continue // we only care about scan responses
+		}
 		select {
 		case ch <- di:
 		case <-ctx.Done():
-			return
+			return nil
 		}
 	}
 }
 
+// udpMagic is the prefix of every UDP command payload.
+var udpMagic = []byte("ABC321")
+
+// parseInfoReply parses an info reply packet into di. Packets that are too
+// short, lack the magic prefix or carry another command are cheaply rejected
+// with false before any XML is decoded, since on a busy network most of what
+// arrives on the reply port isn't worth parsing.
+func parseInfoReply(packet []byte, di *DeviceInfo) (bool, error) {
+	if len(packet) < 17 {
+		return false, nil // invalid, scan results must be > 17 chars
+	}
+	if !bytes.HasPrefix(packet, udpMagic) || commandID(packet[12]) != commandIDInfoReply {
+		return false, nil
+	}
+	if err := xml.Unmarshal(packet[16:], di); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 // makeUDPPayloadShort makes a UDP command payload using default values.
 func makeUDPPayloadShort(cmd commandID) ([]byte, error) {
 	hwAddr, err := net.ParseMAC("FF:FF:FF:FF:FF:FF")
@@ -156,10 +671,18 @@ func makeUDPPayloadShort(cmd commandID) ([]byte, error) {
 	return makeUDPPayload(cmd, hwAddr, nil)
 }
 
+// maxUDPPayloadData is the most data a UDP command payload can carry, since its
+// length is encoded in two bytes.
+const maxUDPPayloadData = 0xFFFF
+
 // makeUDPPayload makes a UDP command payload.
 func makeUDPPayload(cmd commandID, mac net.HardwareAddr, data []byte) ([]byte, error) {
+	if len(data) > maxUDPPayloadData {
+		return nil, fmt.Errorf("payload data length %d exceeds maximum of %d", len(data), maxUDPPayloadData)
+	}
+
 	var buf bytes.Buffer
-	if _, err := buf.Write([]byte("ABC321")); err != nil {
+	if _, err := buf.Write(udpMagic); err != nil {
 		return nil, err
 	}
 	if _, err := buf.Write(mac); err != nil {
@@ -1,10 +1,15 @@
 package heliospectra
 
 import (
+	"bytes"
+	"context"
 	"encoding/xml"
+	"errors"
 	"net"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestUnmarshalDeviceInfo(t *testing.T) {
@@ -42,3 +47,770 @@ func TestUnmarshalDeviceInfo(t *testing.T) {
 		t.Errorf("expected SerialNum=%s, got %s", expectedSerial, di.SerialNum)
 	}
 }
+
+func TestDeviceInfo_NormalizedMAC(t *testing.T) {
+	tests := []struct {
+		mac, expected string
+	}{
+		{mac: "64:1A:10:10:10:10", expected: "64:1a:10:10:10:10"},
+		{mac: "64:1a:10:10:10:10", expected: "64:1a:10:10:10:10"},
+		{mac: "64-1A-10-10-10-10", expected: "64:1a:10:10:10:10"},
+		{mac: "NOT-A-MAC", expected: "not-a-mac"},
+	}
+	for _, tt := range tests {
+		if got := (DeviceInfo{MAC: tt.mac}).NormalizedMAC(); got != tt.expected {
+			t.Errorf("NormalizedMAC(%q): expected %q, got %q", tt.mac, tt.expected, got)
+		}
+	}
+}
+
+func makeInfoReplyXML(t testing.TB, macAddr, xmldata string) []byte {
+	mac, err := net.ParseMAC(macAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := makeUDPPayload(commandIDInfoReply, mac, []byte(xmldata))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return payload
+}
+
+func makeInfoReply(t testing.TB, serial string, padding int) []byte {
+	return makeInfoReplyXML(t, "64:1A:10:10:10:10", "<HelioDevice><MACAddress>64:1A:10:10:10:10</MACAddress>"+
+		"<SerialNr>"+serial+"</SerialNr>"+
+		"<FwVersion>"+strings.Repeat(" ", padding)+"R2.2.25</FwVersion></HelioDevice>")
+}
+
+// startUDPResponder starts a mock device listening for UDP commands on
+// loopback, and returns a udpConfig whose broadcast address and UDP ports
+// point at it. Each received payload is sent on the returned channel, and
+// reply is called to get any replies to send back. The returned func stops the
+// responder.
+func startUDPResponder(t *testing.T, reply func(payload []byte) [][]byte) (*udpConfig, <-chan []byte, func()) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// find a free port for the package to receive replies on:
+	l, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	replyAddr := l.LocalAddr().(*net.UDPAddr)
+	l.Close()
+
+	cfg := defaultUDPConfig()
+	cfg.broadcastIP = net.IPv4(127, 0, 0, 1)
+	// without any interfaces, queries are sent to broadcastIP
+	cfg.listInterfaces = func() ([]localInterface, error) { return nil, nil }
+	cfg.devicePort = conn.LocalAddr().(*net.UDPAddr).Port
+	cfg.listenPort = replyAddr.Port
+
+	received := make(chan []byte, 16)
+	go func() {
+		buf := make([]byte, MaxScanBufferSize)
+		for {
+			n, _, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			payload := append([]byte(nil), buf[:n]...)
+			received <- payload
+			if reply == nil {
+				continue
+			}
+			for _, r := range reply(payload) {
+				if _, err := conn.WriteToUDP(r, replyAddr); err != nil {
+					t.Error(err)
+				}
+			}
+		}
+	}()
+
+	return cfg, received, func() {
+		conn.Close()
+	}
+}
+
+func TestUDPScanReceive_BufferSize(t *testing.T) {
+	sender, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: UDPPort})
+	if err != nil {
+		t.Skipf("unable to bind UDP port %d: %s", UDPPort, err)
+	}
+	defer sender.Close()
+
+	large := makeInfoReply(t, "large", 5000)
+	if len(large) <= DefaultScanBufferSize {
+		t.Fatalf("expected test reply to exceed %d bytes, was %d", DefaultScanBufferSize, len(large))
+	}
+	small := makeInfoReply(t, "small", 0)
+
+	receive := func(bufSize int, payloads ...[]byte) []DeviceInfo {
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer cancel()
+
+		conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		ch := make(chan DeviceInfo)
+		go udpScanReceive(ctx, conn, UDPPort, ch, bufSize, nil)
+		for _, p := range payloads {
+			if _, err := sender.WriteToUDP(p, conn.LocalAddr().(*net.UDPAddr)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		var results []DeviceInfo
+		for {
+			select {
+			case di := <-ch:
+				results = append(results, di)
+			case <-ctx.Done():
+				return results
+			}
+		}
+	}
+
+	results := receive(DefaultScanBufferSize, large, small)
+	if len(results) != 1 || results[0].SerialNum != "small" {
+		t.Errorf("expected only the small reply with the default buffer, got %+v", results)
+	}
+
+	results = receive(8192, large)
+	if len(results) != 1 || results[0].SerialNum != "large" {
+		t.Fatalf("expected the large reply with an 8192 byte buffer, got %+v", results)
+	}
+	if results[0].FwVersion != strings.Repeat(" ", 5000)+"R2.2.25" {
+		t.Errorf("expected the large reply to be decoded in full")
+	}
+}
+
+func TestMakeUDPPayload_DataLength(t *testing.T) {
+	mac, err := net.ParseMAC("64:1A:10:10:10:10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		length   int
+		expected [2]byte
+	}{
+		{length: 1, expected: [2]byte{0x01, 0x00}},
+		{length: 256, expected: [2]byte{0x00, 0x01}},
+		{length: 0x1234, expected: [2]byte{0x34, 0x12}},
+		{length: 0xFFFF, expected: [2]byte{0xFF, 0xFF}},
+	}
+	for _, tt := range tests {
+		payload, err := makeUDPPayload(commandIDSet, mac, make([]byte, tt.length))
+		if err != nil {
+			t.Errorf("length %d: unexpected error: %s", tt.length, err)
+			continue
+		}
+		if got := [2]byte{payload[14], payload[15]}; got != tt.expected {
+			t.Errorf("length %d: expected length bytes %x, got %x", tt.length, tt.expected, got)
+		}
+		if len(payload) != 16+tt.length {
+			t.Errorf("length %d: expected payload of %d bytes, got %d", tt.length, 16+tt.length, len(payload))
+		}
+	}
+
+	if _, err := makeUDPPayload(commandIDSet, mac, make([]byte, 0x10000)); err == nil {
+		t.Errorf("expected an error for oversized data, got none")
+	}
+}
+
+func TestParseInfoReply(t *testing.T) {
+	reply := makeInfoReply(t, "abc123", 0)
+
+	var di DeviceInfo
+	ok, err := parseInfoReply(reply, &di)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("expected an info reply to be parsed")
+	}
+	if di.SerialNum != "abc123" || di.FwVersion != "R2.2.25" {
+		t.Errorf("unexpected DeviceInfo %+v", di)
+	}
+
+	query, err := makeUDPPayloadShort(commandIDQuery)
+	if err != nil {
+		t.Fatal(err)
+	}
+	badMagic := append([]byte("XYZ987"), reply[len(udpMagic):]...)
+	badXML := append(append([]byte{}, reply[:16]...), "<HelioDevice>"...)
+	tests := []struct {
+		name    string
+		packet  []byte
+		wantErr bool
+	}{
+		{name: "short", packet: reply[:16]},
+		{name: "query", packet: query},
+		{name: "bad magic", packet: badMagic},
+		{name: "bad xml", packet: badXML, wantErr: true},
+	}
+	for _, tt := range tests {
+		ok, err := parseInfoReply(tt.packet, &DeviceInfo{})
+		if ok {
+			t.Errorf("%s: expected packet to be rejected", tt.name)
+		}
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: expected error=%t, got %v", tt.name, tt.wantErr, err)
+		}
+	}
+}
+
+func BenchmarkParseInfoReply(b *testing.B) {
+	reply := makeInfoReply(b, "abc123", 0)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var di DeviceInfo
+		if _, err := parseInfoReply(reply, &di); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseInfoReply_Rejected(b *testing.B) {
+	query, err := makeUDPPayloadShort(commandIDQuery)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var di DeviceInfo
+		if _, err := parseInfoReply(query, &di); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestScanOptions_withDefaults(t *testing.T) {
+	opts, err := ScanOptions{}.withDefaults()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.BufferSize != DefaultScanBufferSize {
+		t.Errorf("expected BufferSize=%d, got %d", DefaultScanBufferSize, opts.BufferSize)
+	}
+	if opts.ResultsCapacity != DefaultScanResultsCapacity {
+		t.Errorf("expected ResultsCapacity=%d, got %d", DefaultScanResultsCapacity, opts.ResultsCapacity)
+	}
+	if opts.Timeout != DefaultScanTimeout {
+		t.Errorf("expected Timeout=%s, got %s", DefaultScanTimeout, opts.Timeout)
+	}
+
+	if _, err := (ScanOptions{BufferSize: MaxScanBufferSize + 1}).withDefaults(); err == nil {
+		t.Errorf("expected an error for a buffer larger than MaxScanBufferSize")
+	}
+	if _, err := (ScanOptions{ResultsCapacity: -1}).withDefaults(); err == nil {
+		t.Errorf("expected an error for a negative results capacity")
+	}
+	if _, err := (ScanOptions{Timeout: -time.Second}).withDefaults(); err == nil {
+		t.Errorf("expected an error for a negative timeout")
+	}
+	if _, err := (ScanOptions{ExpectedDevices: -1}).withDefaults(); err == nil {
+		t.Errorf("expected an error for a negative number of expected devices")
+	}
+}
+
+func TestScanUDP_ExpectedDevices(t *testing.T) {
+	cfg, _, stop := startUDPResponder(t, func(payload []byte) [][]byte {
+		return [][]byte{makeInfoReply(t, "first", 0), makeInfoReply(t, "first", 0), makeInfoReply(t, "second", 0)}
+	})
+	defer stop()
+	ctx := withUDPConfig(context.Background(), cfg)
+
+	start := time.Now()
+	results, err := ScanUDPWithOptions(ctx, ScanOptions{ExpectedDevices: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the scan to end after the first reply, took %s", elapsed)
+	}
+	if len(results) != 1 || results[0].SerialNum != "first" {
+		t.Errorf("expected only the first device, got %+v", results)
+	}
+
+	start = time.Now()
+	results, err = ScanUDPWithOptions(ctx, ScanOptions{Timeout: 200 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the scan to end after its 200ms timeout, took %s", elapsed)
+	}
+	if len(results) != 2 {
+		t.Errorf("expected both devices, got %+v", results)
+	}
+}
+
+func TestBroadcastControl(t *testing.T) {
+	var invoked bool
+	dialer := net.Dialer{Control: broadcastControl(func(fd uintptr) error {
+		invoked = true
+		return setBroadcast(fd)
+	})}
+	conn, err := dialer.Dial("udp4", "127.0.0.1:50632")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	if !invoked {
+		t.Errorf("expected the broadcast socket option to be set")
+	}
+
+	sockErr := errors.New("operation not permitted")
+	cfg := defaultUDPConfig()
+	cfg.setBroadcastSockopt = func(fd uintptr) error { return sockErr }
+	_, err = ScanUDP(withUDPConfig(context.Background(), cfg))
+	if err == nil {
+		t.Fatal("expected an error when broadcast can't be enabled, got none")
+	}
+	if !errors.Is(err, sockErr) {
+		t.Errorf("expected error to wrap %v, got %v", sockErr, err)
+	}
+	if !strings.Contains(err.Error(), "broadcast") {
+		t.Errorf("expected an informative error, got %q", err)
+	}
+}
+
+func TestDevice_GetNetworkConfigUDP(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	mac, err := net.ParseMAC("64:1a:10:10:10:10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, received, stop := startUDPResponder(t, func(payload []byte) [][]byte {
+		return [][]byte{
+			makeInfoReplyXML(t, "64:1A:10:10:10:11", `<HelioDevice><MACAddress>64:1A:10:10:10:11</MACAddress><IPAddress>192.168.1.9</IPAddress></HelioDevice>`),
+			makeInfoReplyXML(t, "64:1A:10:10:10:10", `<HelioDevice><MACAddress>64:1A:10:10:10:10</MACAddress><DHCP>false</DHCP><IPAddress>10.0.0.8</IPAddress><NetMask>255.0.0.0</NetMask><Gateway>10.0.0.1</Gateway><DNS1>10.0.0.2</DNS1><DNS2>0.0.0.0</DNS2></HelioDevice>`),
+		}
+	})
+	defer stop()
+	// the query is sent to the Device's address, where the responder listens,
+	// rather than broadcast
+	cfg.broadcastIP = net.IPv4(127, 0, 0, 2)
+	ctx = withUDPConfig(ctx, cfg)
+
+	device := NewDevice(net.IPv4(127, 0, 0, 1), nil)
+	if _, err := device.GetNetworkConfigUDP(ctx, nil); err == nil {
+		t.Errorf("expected an error without a MAC address, got none")
+	}
+	config, err := device.GetNetworkConfigUDP(ctx, mac)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &NetworkConfig{
+		DHCP:    false,
+		IPAddr:  net.IPv4(10, 0, 0, 8),
+		NetMask: "255.0.0.0",
+		Gateway: net.IPv4(10, 0, 0, 1),
+		DNS1:    net.IPv4(10, 0, 0, 2),
+		DNS2:    net.IPv4(0, 0, 0, 0),
+	}
+	if !reflect.DeepEqual(expected, config) {
+		t.Errorf("expected config=%+v\n\tgot %+v", expected, config)
+	}
+
+	query := <-received
+	if cmd := commandID(query[12]); cmd != commandIDQuery {
+		t.Errorf("expected query command %d, got %d", commandIDQuery, cmd)
+	}
+	if !reflect.DeepEqual([]byte(mac), query[6:12]) {
+		t.Errorf("expected query to target %s, got % x", mac, query[6:12])
+	}
+	// without a mac, the Device's own is used
+	device.SetMAC(mac)
+	if config, err = device.GetNetworkConfigUDP(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expected, config) {
+		t.Errorf("expected config=%+v\n\tgot %+v", expected, config)
+	}
+}
+
+func TestDevice_GetNetworkConfigUDP_NoReply(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	cfg, _, stop := startUDPResponder(t, nil)
+	defer stop()
+	ctx = withUDPConfig(ctx, cfg)
+
+	mac, err := net.ParseMAC("64:1a:10:10:10:10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	device := NewDevice(net.IPv4(127, 0, 0, 1), nil)
+	if _, err := device.GetNetworkConfigUDP(ctx, mac); err == nil {
+		t.Errorf("expected an error when the device doesn't reply, got none")
+	}
+}
+
+func TestDevice_Restart(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	device := NewDevice(net.IPv4(127, 0, 0, 1), nil)
+	if err := device.Restart(ctx); err == nil {
+		t.Errorf("expected an error restarting a device without a MAC, got none")
+	}
+
+	cfg, received, stop := startUDPResponder(t, nil)
+	defer stop()
+	ctx = withUDPConfig(ctx, cfg)
+
+	mac, err := net.ParseMAC("64:1A:10:10:10:10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	device.SetMAC(mac)
+	if err := device.Restart(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case payload := <-received:
+		if len(payload) < 13 {
+			t.Fatalf("expected a full payload, got %x", payload)
+		}
+		if !bytes.Equal(payload[6:12], mac) {
+			t.Errorf("expected payload to target %s, got %x", mac, payload[6:12])
+		}
+		if payload[12] != 0x05 {
+			t.Errorf("expected command byte 05, got %02x", payload[12])
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for the restart command")
+	}
+}
+
+func TestMuteUnmuteDevice(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cfg, received, stop := startUDPResponder(t, nil)
+	defer stop()
+	ctx = withUDPConfig(ctx, cfg)
+
+	mac, err := net.ParseMAC("64:1A:10:10:10:10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		send func(context.Context, net.HardwareAddr) error
+		cmd  byte
+	}{
+		{name: "MuteDevice", send: MuteDevice, cmd: 0x03},
+		{name: "UnmuteDevice", send: UnmuteDevice, cmd: 0x01},
+	}
+	for _, tt := range tests {
+		if err := tt.send(ctx, mac); err != nil {
+			t.Fatalf("%s: %s", tt.name, err)
+		}
+		select {
+		case payload := <-received:
+			if !bytes.Equal(payload[6:12], mac) {
+				t.Errorf("%s: expected payload to target %s, got %x", tt.name, mac, payload[6:12])
+			}
+			if payload[12] != tt.cmd {
+				t.Errorf("%s: expected command byte %02x, got %02x", tt.name, tt.cmd, payload[12])
+			}
+		case <-ctx.Done():
+			t.Fatalf("%s: timed out waiting for the command", tt.name)
+		}
+	}
+}
+
+func TestScanUnmutedUDP(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	cfg, received, stop := startUDPResponder(t, func(payload []byte) [][]byte {
+		if payload[12] != 0x02 {
+			return nil
+		}
+		return [][]byte{makeInfoReply(t, "unmuted", 0)}
+	})
+	defer stop()
+	ctx = withUDPConfig(ctx, cfg)
+
+	results, err := ScanUnmutedUDP(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := <-received
+	if payload[12] != 0x02 {
+		t.Errorf("expected command byte 02, got %02x", payload[12])
+	}
+	if len(results) != 1 || results[0].SerialNum != "unmuted" {
+		t.Errorf("expected the unmuted device in the results, got %+v", results)
+	}
+}
+
+func TestScanUDP_ReceiveError(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cfg, _, stop := startUDPResponder(t, nil)
+	defer stop()
+	cfg.listenUDP = func(ctx context.Context, network string, laddr *net.UDPAddr) (*net.UDPConn, error) {
+		conn, err := listenUDP(ctx, network, laddr)
+		if err == nil {
+			conn.Close()
+		}
+		return conn, err
+	}
+	ctx = withUDPConfig(ctx, cfg)
+
+	start := time.Now()
+	if _, err := ScanUDP(ctx); err == nil || !errors.Is(err, net.ErrClosed) {
+		t.Errorf("expected a closed socket error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the scan to fail promptly, took %s", elapsed)
+	}
+}
+
+func TestScanUDP_ContextDuringSetup(t *testing.T) {
+	cfg, _, stop := startUDPResponder(t, nil)
+	defer stop()
+
+	ctx, cancel := context.WithCancel(withUDPConfig(context.Background(), cfg))
+	cancel()
+	start := time.Now()
+	if _, err := ScanUDP(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled from a cancelled scan, got %v", err)
+	}
+
+	// a socket setup that hangs until ctx is done
+	hung := *cfg
+	hung.listenUDP = func(ctx context.Context, network string, laddr *net.UDPAddr) (*net.UDPConn, error) {
+		<-ctx.Done()
+		return nil, errors.New("listen interrupted")
+	}
+	ctx, cancel = context.WithTimeout(withUDPConfig(context.Background(), &hung), 50*time.Millisecond)
+	defer cancel()
+	if _, err := ScanUDP(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded from a hung setup, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the scans to return promptly, took %s", elapsed)
+	}
+}
+
+func TestScanUDP_OnError(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	bad := makeInfoReplyXML(t, "64:1A:10:10:10:10", "<HelioDevice><SerialNr>bad")
+	cfg, _, stop := startUDPResponder(t, func(payload []byte) [][]byte {
+		return [][]byte{bad, makeInfoReply(t, "good", 0)}
+	})
+	defer stop()
+	ctx = withUDPConfig(ctx, cfg)
+
+	var errs []error
+	results, err := ScanUDPWithOptions(ctx, ScanOptions{OnError: func(err error) {
+		errs = append(errs, err)
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].SerialNum != "good" {
+		t.Errorf("expected only the good reply, got %+v", results)
+	}
+	if len(errs) != 1 {
+		t.Errorf("expected 1 decode error, got %v", errs)
+	}
+}
+
+func TestBroadcastAddrs(t *testing.T) {
+	ipnet := func(cidr string) *net.IPNet {
+		ip, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n.IP = ip
+		return n
+	}
+	up := net.FlagUp | net.FlagBroadcast
+	cfg := defaultUDPConfig()
+	cfg.listInterfaces = func() ([]localInterface, error) {
+		return []localInterface{
+			{Name: "lo", Flags: net.FlagUp | net.FlagLoopback, Addrs: []net.Addr{ipnet("127.0.0.1/8")}},
+			{Name: "eth0", Flags: up, Addrs: []net.Addr{ipnet("192.168.1.10/24"), ipnet("192.168.1.11/24"), ipnet("fe80::1/64")}},
+			{Name: "eth1", Flags: up, Addrs: []net.Addr{ipnet("10.20.0.5/16")}},
+			{Name: "wlan0", Flags: net.FlagBroadcast, Addrs: []net.Addr{ipnet("172.16.0.2/24")}},
+			{Name: "tun0", Flags: net.FlagUp | net.FlagPointToPoint, Addrs: []net.Addr{ipnet("10.8.0.2/24")}},
+			{Name: "eth2", Flags: up, Addrs: []net.Addr{ipnet("fe80::2/64")}},
+		}, nil
+	}
+
+	tests := []struct {
+		name     string
+		expected []net.IP
+	}{
+		{name: "", expected: []net.IP{net.IPv4(192, 168, 1, 255), net.IPv4(10, 20, 255, 255)}},
+		{name: "eth1", expected: []net.IP{net.IPv4(10, 20, 255, 255)}},
+	}
+	for _, tt := range tests {
+		addrs, err := broadcastAddrs(cfg, tt.name)
+		if err != nil {
+			t.Errorf("broadcastAddrs(%q): unexpected error: %s", tt.name, err)
+			continue
+		}
+		if len(addrs) != len(tt.expected) {
+			t.Errorf("broadcastAddrs(%q): expected %v, got %v", tt.name, tt.expected, addrs)
+			continue
+		}
+		for i := range addrs {
+			if !addrs[i].Equal(tt.expected[i]) {
+				t.Errorf("broadcastAddrs(%q): expected %v, got %v", tt.name, tt.expected, addrs)
+				break
+			}
+		}
+	}
+
+	if _, err := broadcastAddrs(cfg, "eth9"); err == nil {
+		t.Errorf("expected an error for an unknown interface, got none")
+	}
+	// an interface that's down, can't broadcast or has no IPv4 subnet isn't
+	// silently replaced by the limited broadcast address
+	for _, name := range []string{"wlan0", "tun0", "eth2"} {
+		if addrs, err := broadcastAddrs(cfg, name); err == nil {
+			t.Errorf("broadcastAddrs(%q): expected an error, got %v", name, addrs)
+		}
+	}
+
+	// without a name, the limited broadcast address is the fallback
+	cfg.listInterfaces = func() ([]localInterface, error) {
+		return []localInterface{{Name: "wlan0", Flags: net.FlagBroadcast, Addrs: []net.Addr{ipnet("172.16.0.2/24")}}}, nil
+	}
+	if addrs, err := broadcastAddrs(cfg, ""); err != nil || len(addrs) != 1 || !addrs[0].Equal(cfg.broadcastIP) {
+		t.Errorf("expected the limited broadcast address, got %v, %v", addrs, err)
+	}
+}
+
+func TestScanUDP_PerInterface(t *testing.T) {
+	// The responder is only used for its udpConfig. The mock devices are two
+	// listeners on what the fake interfaces below present as separate
+	// subnets, each a /32 so that its broadcast address is its own address.
+	cfg, _, stop := startUDPResponder(t, nil)
+	defer stop()
+	first, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+	cfg.devicePort = first.LocalAddr().(*net.UDPAddr).Port
+	second, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 2), Port: cfg.devicePort})
+	if err != nil {
+		t.Skipf("unable to bind 127.0.0.2: %s", err)
+	}
+	defer second.Close()
+
+	up := net.FlagUp | net.FlagBroadcast
+	cfg.listInterfaces = func() ([]localInterface, error) {
+		return []localInterface{
+			{Name: "eth0", Flags: up, Addrs: []net.Addr{&net.IPNet{IP: net.IPv4(127, 0, 0, 1), Mask: net.CIDRMask(32, 32)}}},
+			{Name: "eth1", Flags: up, Addrs: []net.Addr{&net.IPNet{IP: net.IPv4(127, 0, 0, 2), Mask: net.CIDRMask(32, 32)}}},
+			{Name: "eth2", Flags: net.FlagBroadcast, Addrs: []net.Addr{&net.IPNet{IP: net.IPv4(127, 0, 0, 3), Mask: net.CIDRMask(32, 32)}}},
+		}, nil
+	}
+
+	queried := func(conn *net.UDPConn) bool {
+		conn.SetReadDeadline(time.Now().Add(500 * time.Millisecond))
+		buf := make([]byte, 64)
+		n, _, err := conn.ReadFromUDP(buf)
+		return err == nil && n > 12 && buf[12] == byte(commandIDQuery)
+	}
+	scan := func(opts ScanOptions) {
+		ctx, cancel := context.WithTimeout(withUDPConfig(context.Background(), cfg), 200*time.Millisecond)
+		defer cancel()
+		if _, err := ScanUDPWithOptions(ctx, opts); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scan(ScanOptions{})
+	if !queried(first) || !queried(second) {
+		t.Errorf("expected a query to be sent on each interface")
+	}
+
+	// mute and unmute commands reach every interface that a scan does
+	mac, err := net.ParseMAC("64:1A:10:10:10:10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	commanded := func(conn *net.UDPConn, cmd commandID) bool {
+		conn.SetReadDeadline(time.Now().Add(500 * time.Millisecond))
+		buf := make([]byte, 64)
+		n, _, err := conn.ReadFromUDP(buf)
+		return err == nil && n > 12 && buf[12] == byte(cmd) && bytes.Equal(buf[6:12], mac)
+	}
+	if err := MuteDevice(withUDPConfig(context.Background(), cfg), mac); err != nil {
+		t.Fatal(err)
+	}
+	if !commanded(first, commandIDMute) || !commanded(second, commandIDMute) {
+		t.Errorf("expected a mute to be sent on each interface")
+	}
+	if err := UnmuteDevice(withUDPConfig(context.Background(), cfg), mac); err != nil {
+		t.Fatal(err)
+	}
+	if !commanded(first, commandIDUnmute) || !commanded(second, commandIDUnmute) {
+		t.Errorf("expected an unmute to be sent on each interface")
+	}
+
+	scan(ScanOptions{Interface: "eth1"})
+	if !queried(second) {
+		t.Errorf("expected a query on eth1")
+	}
+	if queried(first) {
+		t.Errorf("expected no query on eth0 when restricted to eth1")
+	}
+
+	// eth2 is down, and the scan must not fall back to the limited broadcast
+	// address, which the responder's config points at first
+	ctx, cancel := context.WithTimeout(withUDPConfig(context.Background(), cfg), 200*time.Millisecond)
+	defer cancel()
+	if _, err := ScanUDPWithOptions(ctx, ScanOptions{Interface: "eth2"}); err == nil {
+		t.Errorf("expected an error scanning a down interface, got none")
+	}
+	if queried(first) || queried(second) {
+		t.Errorf("expected no query when restricted to a down interface")
+	}
+}
+
+func TestScanUDP_BindError(t *testing.T) {
+	cfg, _, stop := startUDPResponder(t, nil)
+	defer stop()
+
+	// occupy the port that replies are received on:
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(0, 0, 0, 0), Port: cfg.listenPort})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	_, err = ScanUDP(withUDPConfig(context.Background(), cfg))
+	var bindErr *BindError
+	if !errors.As(err, &bindErr) {
+		t.Fatalf("expected a *BindError, got %#v", err)
+	}
+	if bindErr.Port != cfg.listenPort {
+		t.Errorf("expected BindError for port %d, got %d", cfg.listenPort, bindErr.Port)
+	}
+	if !strings.Contains(err.Error(), "in use") {
+		t.Errorf("expected an informative error, got %q", err)
+	}
+}
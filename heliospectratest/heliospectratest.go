@@ -0,0 +1,56 @@
+/*
+Package heliospectratest provides utilities for testing code that controls
+Heliospectra devices.
+*/
+package heliospectratest
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// RedirectClient returns an http.Client that sends every request to the test
+// server at serverURL, such as one created by httptest.NewServer, regardless
+// of the address being requested. This lets a heliospectra.Device created for
+// a fixture's IP be exercised against a mock. The test fails if a request is
+// made to an IP address other than expectedIP, on any port.
+func RedirectClient(t testing.TB, serverURL string, expectedIP net.IP) *http.Client {
+	return RedirectClientPort(t, serverURL, expectedIP, 0)
+}
+
+// RedirectClientPort is like RedirectClient, but the test also fails if a
+// request is made to a port other than expectedPort, which is 80 for a Device
+// using the default HTTP port. If expectedPort is 0, any port is accepted.
+func RedirectClientPort(t testing.TB, serverURL string, expectedIP net.IP, expectedPort int) *http.Client {
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		t.Fatalf("invalid server URL %q: %s", serverURL, err)
+	}
+	serverAddr := u.Host
+
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				host, port, err := net.SplitHostPort(addr)
+				if expectedPort == 0 {
+					if err != nil || !net.ParseIP(host).Equal(expectedIP) {
+						t.Errorf("expected request to be sent to %s, was sent to %s", expectedIP, addr)
+					}
+				} else if err != nil || !net.ParseIP(host).Equal(expectedIP) || port != strconv.Itoa(expectedPort) {
+					expectedAddr := net.JoinHostPort(expectedIP.String(), strconv.Itoa(expectedPort))
+					t.Errorf("expected request to be sent to %s, was sent to %s", expectedAddr, addr)
+				}
+				// override the DialContext func to only dial to our test server:
+				return (&net.Dialer{
+					Timeout:   1 * time.Second,
+					KeepAlive: 1 * time.Second,
+				}).DialContext(ctx, network, serverAddr)
+			},
+		},
+	}
+}
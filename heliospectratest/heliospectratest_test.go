@@ -0,0 +1,73 @@
+package heliospectratest
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type recordingTB struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recordingTB) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestRedirectClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello from " + r.URL.Path))
+	}))
+	defer server.Close()
+
+	testIP := net.IPv4(192, 168, 1, 8)
+	client := RedirectClient(t, server.URL, testIP)
+
+	res, err := client.Get("http://192.168.1.8/diag.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "hello from /diag.xml"; string(body) != expected {
+		t.Errorf("expected body %q, got %q", expected, body)
+	}
+}
+
+func TestRedirectClient_UnexpectedAddr(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	testIP := net.IPv4(192, 168, 1, 8)
+	tests := []struct {
+		url    string
+		port   int
+		errors int
+	}{
+		{url: "http://192.168.1.9/diag.xml", port: 0, errors: 1},
+		{url: "http://192.168.1.8:8080/diag.xml", port: 0, errors: 0},
+		{url: "http://192.168.1.9/diag.xml", port: 80, errors: 1},
+		{url: "http://192.168.1.8:8080/diag.xml", port: 80, errors: 1},
+		{url: "http://192.168.1.8/diag.xml", port: 80, errors: 0},
+	}
+	for _, tt := range tests {
+		rec := &recordingTB{TB: t}
+		client := RedirectClientPort(rec, server.URL, testIP, tt.port)
+
+		res, err := client.Get(tt.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if len(rec.errors) != tt.errors {
+			t.Errorf("%s with port %d: expected %d errors, got %q", tt.url, tt.port, tt.errors, rec.errors)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package heliospectra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// PercentToIntensity converts a percentage of full power into the nearest
+// intensity, rounding halves up. Percentages outside [0, 100] are clamped, and
+// NaN is treated as 0.
+func PercentToIntensity(pct float64) int {
+	if math.IsNaN(pct) {
+		return 0
+	}
+	pct = math.Max(0, math.Min(100, pct))
+	// MaxIntensity/100 is exactly 10, so an intensity's percentage such as
+	// 33.3 maps back to it without floating point drift.
+	return int(math.Round(pct * (MaxIntensity / 100)))
+}
+
+// IntensityToPercent converts an intensity into a percentage of full power.
+func IntensityToPercent(raw int) float64 {
+	return float64(raw) * 100 / MaxIntensity
+}
+
+// SetIntensitiesPercent is like SetIntensities, but takes a percentage of full
+// power for each wavelength, converted with PercentToIntensity.
+func (d *Device) SetIntensitiesPercent(ctx context.Context, pct ...float64) error {
+	intensities := make([]int, len(pct))
+	for i, p := range pct {
+		intensities[i] = PercentToIntensity(p)
+	}
+	return d.SetIntensities(ctx, intensities...)
+}
+
+// PPFDCalibration is a measurement of the photosynthetic photon flux density
+// (PPFD) produced by a fixture with every wavelength at MaxIntensity.
+type PPFDCalibration struct {
+	// PPFD is the measured PPFD in µmol/m²/s.
+	PPFD float64
+	// DistanceM is the distance in meters between the fixture and the sensor
+	// when PPFD was measured.
+	DistanceM float64
+}
+
+// Intensity returns the intensity needed on every wavelength to produce
+// targetPPFD at distanceM meters from the fixture. It assumes PPFD scales
+// linearly with intensity and falls off with the inverse square of the
+// distance. The result is clamped to MaxIntensity when the target can't be
+// reached at that distance.
+func (c PPFDCalibration) Intensity(targetPPFD, distanceM float64) (int, error) {
+	if c.PPFD <= 0 || c.DistanceM <= 0 {
+		return 0, errors.New("invalid PPFDCalibration")
+	}
+	if targetPPFD < 0 {
+		return 0, errors.New("target PPFD must not be negative")
+	}
+	if distanceM <= 0 {
+		return 0, errors.New("distance must be positive")
+	}
+	atDistance := c.PPFD * (c.DistanceM * c.DistanceM) / (distanceM * distanceM)
+	intensity := math.Round(targetPPFD / atDistance * MaxIntensity)
+	if intensity > MaxIntensity {
+		return MaxIntensity, nil
+	}
+	return int(intensity), nil
+}
+
+// SetTargetPPFD sets every wavelength of this Device to the intensity needed to
+// produce targetPPFD at a canopy distanceM meters away, according to cal. The
+// diag is used to determine how many wavelengths the Device has.
+func (d *Device) SetTargetPPFD(ctx context.Context, diag *Diagnostic, cal PPFDCalibration, targetPPFD, distanceM float64) error {
+	intensity, err := cal.Intensity(targetPPFD, distanceM)
+	if err != nil {
+		return err
+	}
+	intensities := make([]int, diag.ChannelCount())
+	for i := range intensities {
+		intensities[i] = intensity
+	}
+	return d.SetIntensities(ctx, intensities...)
+}
+
+// ApplySpectrumCurve sets each wavelength channel of this Device to the fraction
+// of MaxIntensity returned by curve for the channel's wavelength in nanometers.
+// Fractions are clamped to [0,1]. Channels that aren't described by a
+// wavelength, such as white channels described by a color temperature, are set
+// to defaultIntensity instead.
+func (d *Device) ApplySpectrumCurve(ctx context.Context, diag *Diagnostic, curve func(nm int) float64, defaultIntensity int) error {
+	intensities := make([]int, diag.ChannelCount())
+	for i, wl := range diag.Wavelengths {
+		nm, ok := wl.WavelengthNanometers()
+		if !ok {
+			intensities[i] = defaultIntensity
+			continue
+		}
+		fraction := math.Max(0, math.Min(1, curve(nm)))
+		intensities[i] = int(math.Round(fraction * MaxIntensity))
+	}
+	return d.SetIntensities(ctx, intensities...)
+}
+
+// SetIntensitiesGamma sets the intensities of this Device from perceptual
+// brightness percentages, one per wavelength of diag. Each percentage (clamped
+// to [0,100]) is converted to an intensity by applying a gamma curve, so that
+// equal steps in percentage look like equal steps in brightness. A gamma of
+// 2.2 approximates human perception; a gamma of 1 is linear.
+func (d *Device) SetIntensitiesGamma(ctx context.Context, diag *Diagnostic, gamma float64, perceptualPct ...float64) error {
+	if gamma <= 0 {
+		return errors.New("gamma must be positive")
+	}
+	if len(perceptualPct) != diag.ChannelCount() {
+		return fmt.Errorf("got %d percentages for %d wavelengths", len(perceptualPct), diag.ChannelCount())
+	}
+	intensities := make([]int, len(perceptualPct))
+	for i, pct := range perceptualPct {
+		intensities[i] = gammaIntensity(pct, gamma)
+	}
+	return d.SetIntensities(ctx, intensities...)
+}
+
+func gammaIntensity(pct, gamma float64) int {
+	fraction := math.Max(0, math.Min(100, pct)) / 100
+	return int(math.Round(math.Pow(fraction, gamma) * MaxIntensity))
+}
+
+// SunriseStep sets the intensities of this Device for a point in a simulated
+// day, where dayFraction is the progress through the photoperiod from 0
+// (sunrise) to 1 (sunset). Intensities follow a bell curve, sin²(π·dayFraction),
+// that starts and ends at zero and reaches peak at midday. There must be one
+// peak intensity per wavelength of diag. Calling this periodically, such as
+// from cron, produces a natural day without programming a schedule.
+func (d *Device) SunriseStep(ctx context.Context, diag *Diagnostic, dayFraction float64, peak []int) error {
+	if dayFraction < 0 || dayFraction > 1 {
+		return fmt.Errorf("day fraction %v out of range [0,1]", dayFraction)
+	}
+	if len(peak) != diag.ChannelCount() {
+		return fmt.Errorf("got %d peak intensities for %d wavelengths", len(peak), diag.ChannelCount())
+	}
+	return d.SetIntensities(ctx, sunriseIntensities(dayFraction, peak)...)
+}
+
+func sunriseIntensities(dayFraction float64, peak []int) []int {
+	level := math.Pow(math.Sin(math.Pi*dayFraction), 2)
+	intensities := make([]int, len(peak))
+	for i, p := range peak {
+		intensities[i] = int(math.Round(level * float64(p)))
+	}
+	return intensities
+}
+
+// IntensitiesApproxEqual reports whether a and b have the same length and each
+// pair of intensities differs by no more than tolerance. This accounts for
+// firmware rounding the values it's sent.
+func IntensitiesApproxEqual(a, b []int, tolerance int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		diff := a[i] - b[i]
+		if diff < -tolerance || diff > tolerance {
+			return false
+		}
+	}
+	return true
+}
+
+// EnsureIntensities sets the intensities of this Device to desired unless they
+// already are, and reports whether they were changed. This suits
+// reconciliation loops that apply a desired state repeatedly, since a fixture
+// that's already in that state isn't sent a redundant update.
+func (d *Device) EnsureIntensities(ctx context.Context, desired ...int) (changed bool, err error) {
+	// compare what SetIntensities would actually send, such as after raising
+	// to MinIntensities, so a floored channel doesn't always look changed
+	adjusted, err := d.adjustIntIntensities(ctx, desired, false)
+	if err != nil {
+		return false, err
+	}
+	current, err := d.GetIntensities(ctx)
+	if err != nil {
+		return false, err
+	}
+	if IntensitiesApproxEqual(current, adjusted, 0) {
+		return false, nil
+	}
+	if err := d.sendIntensities(ctx, adjusted); err != nil {
+		return false, err
+	}
+	return true, nil
+}
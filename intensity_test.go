@@ -0,0 +1,329 @@
+package heliospectra
+
+import (
+	"context"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPercentToIntensity(t *testing.T) {
+	tests := []struct {
+		pct      float64
+		expected int
+	}{
+		{pct: 0, expected: 0},
+		{pct: 50, expected: 500},
+		{pct: 100, expected: 1000},
+		{pct: 33.333, expected: 333},
+		{pct: 33.35, expected: 334},
+		{pct: 0.05, expected: 1},
+		{pct: 0.04, expected: 0},
+		{pct: -10, expected: 0},
+		{pct: 150, expected: 1000},
+		{pct: math.NaN(), expected: 0},
+	}
+	for _, tt := range tests {
+		if got := PercentToIntensity(tt.pct); got != tt.expected {
+			t.Errorf("PercentToIntensity(%v): expected %d, got %d", tt.pct, tt.expected, got)
+		}
+	}
+
+	for raw := 0; raw <= MaxIntensity; raw++ {
+		if got := PercentToIntensity(IntensityToPercent(raw)); got != raw {
+			t.Fatalf("expected intensity %d to round trip, got %d", raw, got)
+		}
+	}
+	if pct := IntensityToPercent(333); pct != 33.3 {
+		t.Errorf("expected IntensityToPercent(333)=33.3, got %v", pct)
+	}
+}
+
+func TestDevice_SetIntensitiesPercent(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var gotQuery string
+	device, server := newTestDevice(t, func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.Query().Get("int")
+	})
+	defer server.Close()
+
+	if err := device.SetIntensitiesPercent(ctx, 0, 50, 100, 33.333); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "0:500:1000:333"; gotQuery != expected {
+		t.Errorf("expected int=%s, got %s", expected, gotQuery)
+	}
+}
+
+func TestPPFDCalibration_Intensity(t *testing.T) {
+	cal := PPFDCalibration{PPFD: 800, DistanceM: 0.5}
+
+	tests := []struct {
+		target, distance float64
+		expected         int
+	}{
+		{target: 200, distance: 0.5, expected: 250},
+		{target: 200, distance: 1.0, expected: 1000},
+		{target: 100, distance: 1.0, expected: 500},
+		{target: 200, distance: 1.5, expected: 1000},
+		{target: 0, distance: 1.0, expected: 0},
+	}
+	for _, tt := range tests {
+		got, err := cal.Intensity(tt.target, tt.distance)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.expected {
+			t.Errorf("Intensity(%v, %v): expected %d, got %d", tt.target, tt.distance, tt.expected, got)
+		}
+	}
+
+	near, err := cal.Intensity(40, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	far, err := cal.Intensity(40, 1.0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if far != 4*near {
+		t.Errorf("expected doubling distance to quadruple intensity, got %d and %d", near, far)
+	}
+
+	if _, err := cal.Intensity(100, 0); err == nil {
+		t.Errorf("expected an error for a zero distance")
+	}
+	if _, err := cal.Intensity(-1, 1); err == nil {
+		t.Errorf("expected an error for a negative target")
+	}
+	if _, err := (PPFDCalibration{}).Intensity(100, 1); err == nil {
+		t.Errorf("expected an error for a zero calibration")
+	}
+}
+
+func TestDevice_SetTargetPPFD(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var gotQuery string
+	device, server := newTestDevice(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/intensity.cgi" {
+			t.Errorf("expected URL /intensity.cgi, got %s", r.URL.Path)
+		}
+		gotQuery = r.URL.Query().Get("int")
+	})
+	defer server.Close()
+
+	diag := &Diagnostic{Wavelengths: make(WavelengthList, 4)}
+	cal := PPFDCalibration{PPFD: 800, DistanceM: 0.5}
+	if err := device.SetTargetPPFD(ctx, diag, cal, 200, 0.5); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "250:250:250:250"; gotQuery != expected {
+		t.Errorf("expected int=%s, got %s", expected, gotQuery)
+	}
+}
+
+func TestDevice_ApplySpectrumCurve(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var gotQuery string
+	device, server := newTestDevice(t, func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.Query().Get("int")
+	})
+	defer server.Close()
+
+	diag := &Diagnostic{
+		Wavelengths: WavelengthList{
+			{Number: 0, Wavelength: "450nm", Power: "10.2W"},
+			{Number: 1, Wavelength: "660nm", Power: "5.2W"},
+			{Number: 2, Wavelength: "735nm", Power: "10.0W"},
+			{Number: 3, Wavelength: "5700K", Power: "6.0W"},
+		},
+	}
+	curve := func(nm int) float64 {
+		switch {
+		case nm < 500:
+			return 1.5 // clamped to 1
+		case nm < 700:
+			return 0.25
+		default:
+			return -1 // clamped to 0
+		}
+	}
+	if err := device.ApplySpectrumCurve(ctx, diag, curve, 300); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "1000:250:0:300"; gotQuery != expected {
+		t.Errorf("expected int=%s, got %s", expected, gotQuery)
+	}
+}
+
+func TestDevice_SetIntensitiesGamma(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	requests := 0
+	var gotQuery string
+	device, server := newTestDevice(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		gotQuery = r.URL.Query().Get("int")
+	})
+	defer server.Close()
+
+	diag := &Diagnostic{Wavelengths: make(WavelengthList, 4)}
+	if err := device.SetIntensitiesGamma(ctx, diag, 2.2, 0, 50, 100, 150); err != nil {
+		t.Fatal(err)
+	}
+	// 0.5^2.2 = 0.2176
+	if expected := "0:218:1000:1000"; gotQuery != expected {
+		t.Errorf("expected int=%s, got %s", expected, gotQuery)
+	}
+
+	if err := device.SetIntensitiesGamma(ctx, diag, 1, 50, 50, 50, 50); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "500:500:500:500"; gotQuery != expected {
+		t.Errorf("expected linear int=%s, got %s", expected, gotQuery)
+	}
+
+	if err := device.SetIntensitiesGamma(ctx, diag, 2.2, 50); err == nil {
+		t.Errorf("expected an error for the wrong number of percentages, got none")
+	}
+	if err := device.SetIntensitiesGamma(ctx, diag, 0, 50, 50, 50, 50); err == nil {
+		t.Errorf("expected an error for a zero gamma, got none")
+	}
+	if requests != 2 {
+		t.Errorf("expected 2 requests, got %d", requests)
+	}
+}
+
+func TestSunriseIntensities(t *testing.T) {
+	peak := []int{1000, 800, 0, 500}
+
+	tests := []struct {
+		fraction float64
+		expected []int
+	}{
+		{fraction: 0, expected: []int{0, 0, 0, 0}},
+		{fraction: 0.01, expected: []int{1, 1, 0, 0}},
+		{fraction: 0.25, expected: []int{500, 400, 0, 250}},
+		{fraction: 0.5, expected: []int{1000, 800, 0, 500}},
+		{fraction: 0.75, expected: []int{500, 400, 0, 250}},
+		{fraction: 1, expected: []int{0, 0, 0, 0}},
+	}
+	for _, tt := range tests {
+		if got := sunriseIntensities(tt.fraction, peak); !reflect.DeepEqual(tt.expected, got) {
+			t.Errorf("sunriseIntensities(%v): expected %v, got %v", tt.fraction, tt.expected, got)
+		}
+	}
+}
+
+func TestDevice_SunriseStep(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var gotQuery string
+	device, server := newTestDevice(t, func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.Query().Get("int")
+	})
+	defer server.Close()
+
+	diag := &Diagnostic{Wavelengths: make(WavelengthList, 4)}
+	if err := device.SunriseStep(ctx, diag, 0.5, []int{1000, 800, 0, 500}); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "1000:800:0:500"; gotQuery != expected {
+		t.Errorf("expected int=%s, got %s", expected, gotQuery)
+	}
+
+	if err := device.SunriseStep(ctx, diag, 1.5, []int{1000, 800, 0, 500}); err == nil {
+		t.Errorf("expected an error for a day fraction above 1, got none")
+	}
+	if err := device.SunriseStep(ctx, diag, 0.5, []int{1000}); err == nil {
+		t.Errorf("expected an error for the wrong number of peak intensities, got none")
+	}
+}
+
+func TestIntensitiesApproxEqual(t *testing.T) {
+	tests := []struct {
+		a, b      []int
+		tolerance int
+		expected  bool
+	}{
+		{a: []int{0, 500, 1000}, b: []int{0, 500, 1000}, tolerance: 0, expected: true},
+		{a: []int{0, 500, 1000}, b: []int{1, 498, 1000}, tolerance: 2, expected: true},
+		{a: []int{0, 500, 1000}, b: []int{0, 503, 1000}, tolerance: 2, expected: false},
+		{a: []int{0, 500, 1000}, b: []int{0, 501, 1000}, tolerance: 0, expected: false},
+		{a: []int{0, 500}, b: []int{0, 500, 1000}, tolerance: 10, expected: false},
+		{a: nil, b: []int{}, tolerance: 0, expected: true},
+	}
+	for _, tt := range tests {
+		if got := IntensitiesApproxEqual(tt.a, tt.b, tt.tolerance); got != tt.expected {
+			t.Errorf("IntensitiesApproxEqual(%v, %v, %d): expected %t, got %t", tt.a, tt.b, tt.tolerance, tt.expected, got)
+		}
+	}
+}
+
+func TestDevice_EnsureIntensities(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var writes []string
+	status := statusResponse
+	device, server := newTestDevice(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/status.xml":
+			w.Write([]byte(status))
+		case "/intensity.cgi":
+			writes = append(writes, r.URL.Query().Get("int"))
+		default:
+			t.Errorf("unexpected request for %s", r.URL.Path)
+		}
+	})
+	defer server.Close()
+
+	tests := []struct {
+		desired        []int
+		expectedWrites []string
+	}{
+		{desired: []int{0, 0, 0, 0}},
+		{desired: []int{0, 100, 0, 0}, expectedWrites: []string{"0:100:0:0"}},
+	}
+	for _, tt := range tests {
+		writes = nil
+		changed, err := device.EnsureIntensities(ctx, tt.desired...)
+		if err != nil {
+			t.Errorf("%v: unexpected error: %s", tt.desired, err)
+			continue
+		}
+		if expected := len(tt.expectedWrites) > 0; changed != expected {
+			t.Errorf("%v: expected changed=%t, got %t", tt.desired, expected, changed)
+		}
+		if !reflect.DeepEqual(tt.expectedWrites, writes) {
+			t.Errorf("%v: expected writes %q, got %q", tt.desired, tt.expectedWrites, writes)
+		}
+	}
+
+	// desired intensities are compared after raising them to the floor, so
+	// one below the floor that the device already reports isn't resent
+	device.Logger = log.New(io.Discard, "", 0)
+	device.MinIntensities = []int{0, 100}
+	status = strings.Replace(statusResponse, "<j>0:0,1:0,2:0,3:0,</j>", "<j>0:0,1:100,2:0,3:0,</j>", 1)
+	writes = nil
+	changed, err := device.EnsureIntensities(ctx, 0, 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed || writes != nil {
+		t.Errorf("expected no write for a floored channel, got changed=%t writes %q", changed, writes)
+	}
+}
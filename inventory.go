@@ -0,0 +1,89 @@
+package heliospectra
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// EnrichedDevice is a device found during a scan along with its diagnostic, if
+// one was fetched.
+type EnrichedDevice struct {
+	Info       DeviceInfo
+	Diagnostic *Diagnostic
+}
+
+// Inventory formats supported by ExportInventory.
+const (
+	InventoryFormatCSV  = "csv"
+	InventoryFormatJSON = "json"
+)
+
+type inventoryRow struct {
+	Serial   string `json:"serial"`
+	MAC      string `json:"mac"`
+	IP       string `json:"ip"`
+	Model    string `json:"model"`
+	Firmware string `json:"firmware"`
+	Channels int    `json:"channels"`
+}
+
+func newInventoryRow(ed EnrichedDevice) inventoryRow {
+	row := inventoryRow{
+		Serial:   ed.Info.SerialNum,
+		MAC:      ed.Info.NormalizedMAC(),
+		Firmware: ed.Info.FwVersion,
+	}
+	if ed.Info.IPAddr != nil {
+		row.IP = ed.Info.IPAddr.String()
+	}
+	if ed.Diagnostic != nil {
+		row.Model = ed.Diagnostic.Model
+		row.Channels = ed.Diagnostic.ChannelCount()
+		if row.Firmware == "" {
+			row.Firmware = ed.Diagnostic.CPUFW
+		}
+	}
+	return row
+}
+
+// ExportInventory writes one record per device to w with its serial number,
+// MAC address, IP address, model, firmware version and channel count. MAC
+// addresses are normalized with DeviceInfo.NormalizedMAC, so that inventories
+// from different scans can be joined on them. The format must be either
+// InventoryFormatCSV, which includes a header row, or InventoryFormatJSON,
+// which writes an array of objects. The model and channel count are left empty
+// for devices without a Diagnostic.
+func ExportInventory(w io.Writer, devices []EnrichedDevice, format string) error {
+	rows := make([]inventoryRow, len(devices))
+	for i, ed := range devices {
+		rows[i] = newInventoryRow(ed)
+	}
+
+	switch format {
+	case InventoryFormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write([]string{"serial", "mac", "ip", "model", "firmware", "channels"}); err != nil {
+			return err
+		}
+		for _, row := range rows {
+			channels := ""
+			if row.Channels > 0 {
+				channels = strconv.Itoa(row.Channels)
+			}
+			if err := cw.Write([]string{row.Serial, row.MAC, row.IP, row.Model, row.Firmware, channels}); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case InventoryFormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	default:
+		return fmt.Errorf("unsupported inventory format %q", format)
+	}
+}
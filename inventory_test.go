@@ -0,0 +1,83 @@
+package heliospectra
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"reflect"
+	"testing"
+)
+
+var testInventory = []EnrichedDevice{
+	{
+		Info: DeviceInfo{
+			MAC:       "64:1A:10:10:10:10",
+			IPAddr:    net.IPv4(192, 168, 1, 8),
+			FwVersion: "R2.2.25",
+			SerialNum: "fcaaaaaaaaaa",
+		},
+		Diagnostic: &Diagnostic{
+			Model:       "L4",
+			Wavelengths: make(WavelengthList, 4),
+		},
+	},
+	{
+		Info: DeviceInfo{
+			MAC:       "64-1a-10-10-10-11",
+			IPAddr:    net.IPv4(192, 168, 1, 9),
+			FwVersion: "R2.2.20",
+			SerialNum: "fcbbbbbbbbbb",
+		},
+	},
+}
+
+func TestExportInventory_CSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := ExportInventory(&buf, testInventory, InventoryFormatCSV); err != nil {
+		t.Fatal(err)
+	}
+	expected := "serial,mac,ip,model,firmware,channels\n" +
+		"fcaaaaaaaaaa,64:1a:10:10:10:10,192.168.1.8,L4,R2.2.25,4\n" +
+		"fcbbbbbbbbbb,64:1a:10:10:10:11,192.168.1.9,,R2.2.20,\n"
+	if buf.String() != expected {
+		t.Errorf("expected CSV:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
+
+func TestExportInventory_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := ExportInventory(&buf, testInventory, InventoryFormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	var rows []map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &rows); err != nil {
+		t.Fatal(err)
+	}
+	expected := []map[string]interface{}{
+		{
+			"serial":   "fcaaaaaaaaaa",
+			"mac":      "64:1a:10:10:10:10",
+			"ip":       "192.168.1.8",
+			"model":    "L4",
+			"firmware": "R2.2.25",
+			"channels": float64(4),
+		},
+		{
+			"serial":   "fcbbbbbbbbbb",
+			"mac":      "64:1a:10:10:10:11",
+			"ip":       "192.168.1.9",
+			"model":    "",
+			"firmware": "R2.2.20",
+			"channels": float64(0),
+		},
+	}
+	if !reflect.DeepEqual(expected, rows) {
+		t.Errorf("expected JSON rows=%#v\n\tgot %#v", expected, rows)
+	}
+}
+
+func TestExportInventory_UnknownFormat(t *testing.T) {
+	if err := ExportInventory(&bytes.Buffer{}, testInventory, "xml"); err == nil {
+		t.Errorf("expected an error for an unsupported format, got none")
+	}
+}
@@ -0,0 +1,60 @@
+package heliospectra
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// A DeviceOption configures a Device created with NewDeviceWithOptions.
+type DeviceOption func(*Device)
+
+// WithHTTPPort sets the port of the Device's HTTP server, for firmware or
+// reverse proxies that don't serve it on the scheme's default port.
+func WithHTTPPort(port int) DeviceOption {
+	return func(d *Device) { d.port = port }
+}
+
+// WithScheme sets the URL scheme used to reach the Device, such as "https"
+// behind a TLS-terminating proxy. The default is "http".
+func WithScheme(scheme string) DeviceOption {
+	return func(d *Device) { d.scheme = scheme }
+}
+
+// WithClient sets the HTTP client used to reach the Device. The default is
+// http.DefaultClient.
+func WithClient(client *http.Client) DeviceOption {
+	return func(d *Device) {
+		if client != nil {
+			d.client = client
+		}
+	}
+}
+
+// WithRequestTimeout sets the Device's HTTPTimeout.
+func WithRequestTimeout(timeout time.Duration) DeviceOption {
+	return func(d *Device) { d.HTTPTimeout = timeout }
+}
+
+// WithRetry makes the Device retry requests that fail with a connection error
+// or a 5xx status, such as a 503 from a fixture busy recomputing its light
+// setting, for up to attempts attempts in total. The wait between attempts
+// starts at backoff and doubles after each one. Requests that fail with a 4xx
+// status aren't retried, and retrying stops once the request's context is
+// done.
+func WithRetry(attempts int, backoff time.Duration) DeviceOption {
+	return func(d *Device) {
+		d.retryAttempts = attempts
+		d.retryBackoff = backoff
+	}
+}
+
+// NewDeviceWithOptions creates a new device from an IP address, configured by
+// opts.
+func NewDeviceWithOptions(addr net.IP, opts ...DeviceOption) *Device {
+	d := NewDevice(addr, nil)
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
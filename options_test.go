@@ -0,0 +1,185 @@
+package heliospectra
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// serverPort returns the port that server is listening on.
+func serverPort(t *testing.T, server *httptest.Server) int {
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, err := strconv.Atoi(u.Port())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return port
+}
+
+func TestNewDeviceWithOptions(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/status.xml" {
+			t.Errorf("expected URL /status.xml, got %s", r.URL.Path)
+		}
+		w.Write([]byte(statusResponse))
+	}))
+	defer server.Close()
+	port := serverPort(t, server)
+
+	client := &http.Client{}
+	device := NewDeviceWithOptions(net.IPv4(127, 0, 0, 1),
+		WithHTTPPort(port),
+		WithScheme("http"),
+		WithClient(client),
+		WithRequestTimeout(2*time.Second),
+	)
+	if device.client != client {
+		t.Errorf("expected the passed-in client to be used")
+	}
+	if device.HTTPTimeout != 2*time.Second {
+		t.Errorf("expected HTTPTimeout=2s, got %s", device.HTTPTimeout)
+	}
+	if _, err := device.Status(ctx); err != nil {
+		t.Fatalf("expected the status to be read from port %d, got %s", port, err)
+	}
+
+	device = NewDeviceWithOptions(net.IPv4(127, 0, 0, 1))
+	if device.client != http.DefaultClient || device.port != 0 || device.scheme != "" {
+		t.Errorf("expected defaults without options, got client=%p port=%d scheme=%q", device.client, device.port, device.scheme)
+	}
+}
+
+func TestDevice_HTTPS(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/diag.xml" {
+			t.Errorf("expected URL /diag.xml, got %s", r.URL.Path)
+		}
+		w.Write([]byte(diagResponse))
+	}))
+	defer server.Close()
+
+	device := NewDeviceWithOptions(net.IPv4(127, 0, 0, 1),
+		WithScheme("https"),
+		WithHTTPPort(serverPort(t, server)),
+		WithClient(server.Client()),
+	)
+	if _, err := device.Diagnostic(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// without a client that trusts the server's certificate:
+	device = NewDeviceWithOptions(net.IPv4(127, 0, 0, 1),
+		WithScheme("https"),
+		WithHTTPPort(serverPort(t, server)),
+	)
+	if _, err := device.Diagnostic(ctx); err == nil {
+		t.Errorf("expected a certificate error, got none")
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var requests, failures, failStatus int
+	device, server := newTestDevice(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests <= failures {
+			w.WriteHeader(failStatus)
+			return
+		}
+		w.Write([]byte(statusResponse))
+	})
+	defer server.Close()
+	device.Logger = log.New(io.Discard, "", 0)
+	WithRetry(3, time.Millisecond)(device)
+
+	tests := []struct {
+		failures, failStatus int
+		expectedRequests     int
+		expectErr            bool
+	}{
+		{failures: 2, failStatus: 503, expectedRequests: 3},
+		{failures: 5, failStatus: 500, expectedRequests: 3, expectErr: true},
+		{failures: 1, failStatus: 404, expectedRequests: 1, expectErr: true},
+	}
+	for _, tt := range tests {
+		requests, failures, failStatus = 0, tt.failures, tt.failStatus
+		_, err := device.Status(ctx)
+		if (err != nil) != tt.expectErr {
+			t.Errorf("%d failures with status %d: expected error=%t, got %v", tt.failures, tt.failStatus, tt.expectErr, err)
+		}
+		if requests != tt.expectedRequests {
+			t.Errorf("%d failures with status %d: expected %d requests, got %d", tt.failures, tt.failStatus, tt.expectedRequests, requests)
+		}
+	}
+
+	// cancelling the context stops the retries during the backoff
+	requests, failures, failStatus = 0, 5, 503
+	WithRetry(5, time.Hour)(device)
+	cancelCtx, cancelNow := context.WithCancel(ctx)
+	time.AfterFunc(20*time.Millisecond, cancelNow)
+	if _, err := device.Status(cancelCtx); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("expected 1 request before cancellation, got %d", requests)
+	}
+}
+
+func TestWithRetry_ConnectionErrors(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// a refused connection is retried
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closedPort := serverPort(t, closed)
+	closed.Close()
+	attempts := 0
+	device := NewDeviceWithOptions(net.IPv4(127, 0, 0, 1), WithHTTPPort(closedPort), WithRetry(3, time.Millisecond))
+	device.Logger = log.New(io.Discard, "", 0)
+	device.OnRequest = func(*http.Request) { attempts++ }
+	if err := device.Ping(ctx); err == nil {
+		t.Errorf("expected an error from a closed port, got none")
+	}
+	if attempts != 3 {
+		t.Errorf("expected a refused connection to be attempted 3 times, got %d", attempts)
+	}
+
+	// a certificate failure isn't
+	server := httptest.NewUnstartedServer(http.NotFoundHandler())
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+	attempts = 0
+	device = NewDeviceWithOptions(net.IPv4(127, 0, 0, 1), WithScheme("https"), WithHTTPPort(serverPort(t, server)), WithRetry(4, 200*time.Millisecond))
+	device.Logger = log.New(io.Discard, "", 0)
+	device.OnRequest = func(*http.Request) { attempts++ }
+	start := time.Now()
+	if err := device.Ping(ctx); err == nil {
+		t.Errorf("expected a certificate error, got none")
+	}
+	if attempts != 1 {
+		t.Errorf("expected a certificate failure to be attempted once, got %d", attempts)
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("expected a certificate failure to return without backing off, took %s", elapsed)
+	}
+}
@@ -0,0 +1,185 @@
+package heliospectra
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// splitIndexed splits a comma-separated list of index:value pairs, such as
+// "0:0,1:0,2:0,3:0,", into its values. The indexes must start at 0 and be
+// contiguous. An empty or whitespace-only string yields no values.
+func splitIndexed(s string) ([]string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return []string{}, nil
+	}
+
+	parts := strings.Split(strings.TrimRight(s, ","), ",")
+	values := make([]string, 0, len(parts))
+	for i, part := range parts {
+		items := strings.SplitN(part, ":", 2)
+		if len(items) != 2 {
+			return nil, fmt.Errorf("invalid index:value pair %q", part)
+		}
+		idx, err := strconv.Atoi(strings.TrimSpace(items[0]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid index in pair %q", part)
+		}
+		if idx != i {
+			return nil, fmt.Errorf("index %d out of order, expected %d", idx, i)
+		}
+		values = append(values, strings.TrimSpace(items[1]))
+	}
+	return values, nil
+}
+
+// parseIntensities parses an intensities field such as "0:0,1:0,2:0,3:0," into
+// a slice of intensities ordered by wavelength number.
+func parseIntensities(s string) ([]int, error) {
+	values, err := splitIndexed(s)
+	if err != nil {
+		return nil, err
+	}
+	intensities := make([]int, len(values))
+	for i, v := range values {
+		intensity, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid intensity %q at index %d", v, i)
+		}
+		intensities[i] = intensity
+	}
+	return intensities, nil
+}
+
+// parseUnitFloat parses a number followed by unit, such as "5.2W".
+func parseUnitFloat(s, unit string) (float64, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasSuffix(s, unit) {
+		return 0, fmt.Errorf("missing unit %q in %q", unit, s)
+	}
+	return strconv.ParseFloat(strings.TrimSuffix(s, unit), 64)
+}
+
+// parseNanometers parses a wavelength such as "660nm" into its number of
+// nanometers. It returns false for values that aren't in nanometers, such as
+// a color temperature like "5700K".
+func parseNanometers(s string) (int, bool) {
+	if !strings.HasSuffix(s, "nm") {
+		return 0, false
+	}
+	nm, err := strconv.Atoi(strings.TrimSuffix(s, "nm"))
+	if err != nil || nm <= 0 {
+		return 0, false
+	}
+	return nm, true
+}
+
+// parseChangeTime parses a last change timestamp such as "2017-03-17	18:58:34".
+// Devices report local wall-clock time without a zone, so the time is
+// interpreted in loc.
+func parseChangeTime(s string, loc *time.Location) (time.Time, error) {
+	return time.ParseInLocation("2006-01-02 15:04:05", strings.Join(strings.Fields(s), " "), loc)
+}
+
+// ParseUptime parses an uptime such as "0d 02h 39m 37s", as reported in
+// Diagnostic.Runtime and Status.Uptime, into a duration. Fields may be zero
+// padded or not, and leading fields may be omitted, as in "12m 0s".
+func ParseUptime(s string) (time.Duration, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("invalid uptime %q", s)
+	}
+	units := []struct {
+		suffix string
+		unit   time.Duration
+	}{
+		{"d", 24 * time.Hour},
+		{"h", time.Hour},
+		{"m", time.Minute},
+		{"s", time.Second},
+	}
+	var total time.Duration
+	next := 0
+	for _, field := range fields {
+		found := false
+		for next < len(units) {
+			u := units[next]
+			next++
+			if !strings.HasSuffix(field, u.suffix) {
+				continue
+			}
+			n, err := strconv.ParseUint(strings.TrimSuffix(field, u.suffix), 10, 32)
+			if err != nil {
+				return 0, fmt.Errorf("invalid uptime %q", s)
+			}
+			total += time.Duration(n) * u.unit
+			found = true
+			break
+		}
+		if !found {
+			return 0, fmt.Errorf("invalid uptime %q", s)
+		}
+	}
+	return total, nil
+}
+
+// deviceClockLayout is the layout of a device clock, such as
+// "2017:03:17:02:48:41".
+const deviceClockLayout = "2006:01:02:15:04:05"
+
+// ParseDeviceClock parses a device clock such as "2017:03:17:02:48:41", as
+// reported in Diagnostic.Clock and Status.InternalTime. Devices report local
+// wall-clock time without a zone, so the time is interpreted in loc, or in
+// time.Local if loc is nil.
+func ParseDeviceClock(s string, loc *time.Location) (time.Time, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	return time.ParseInLocation(deviceClockLayout, strings.TrimSpace(s), loc)
+}
+
+// FormatDeviceClock formats t as a device clock in t's location, the inverse of
+// ParseDeviceClock. Callers should convert t to the device's time zone first.
+func FormatDeviceClock(t time.Time) string {
+	return t.Format(deviceClockLayout)
+}
+
+// parseNTPOffset parses an NTP offset such as "00:00:00" or "-05:30:00" into a
+// duration.
+func parseNTPOffset(offset string) (time.Duration, error) {
+	s := strings.TrimSpace(offset)
+	sign := time.Duration(1)
+	if strings.HasPrefix(s, "-") {
+		sign = -1
+		s = s[1:]
+	} else {
+		s = strings.TrimPrefix(s, "+")
+	}
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid NTP offset %q", offset)
+	}
+	var total time.Duration
+	for i, unit := range []time.Duration{time.Hour, time.Minute, time.Second} {
+		n, err := strconv.ParseUint(parts[i], 10, 32)
+		if err != nil || (i > 0 && n >= 60) {
+			return 0, fmt.Errorf("invalid NTP offset %q", offset)
+		}
+		total += time.Duration(n) * unit
+	}
+	return sign * total, nil
+}
+
+// FormatNTPOffset formats an offset the way devices report and accept it, such
+// as "-05:30:00". The offset is truncated to whole seconds.
+func FormatNTPOffset(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+	secs := int64(d / time.Second)
+	return fmt.Sprintf("%s%02d:%02d:%02d", sign, secs/3600, secs/60%60, secs%60)
+}
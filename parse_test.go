@@ -0,0 +1,147 @@
+package heliospectra
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseIntensities(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected []int
+	}{
+		{in: "0:0,1:0,2:0,3:0,", expected: []int{0, 0, 0, 0}},
+		{in: "0:10,1:1000,2:5", expected: []int{10, 1000, 5}},
+		{in: "", expected: []int{}},
+		{in: "  ", expected: []int{}},
+	}
+	for _, tt := range tests {
+		got, err := parseIntensities(tt.in)
+		if err != nil {
+			t.Errorf("parseIntensities(%q): unexpected error: %s", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(tt.expected, got) {
+			t.Errorf("parseIntensities(%q): expected %v, got %v", tt.in, tt.expected, got)
+		}
+	}
+
+	for _, in := range []string{"0:0,2:0,", "1:0,", "0:a,", "0:0,1", "x:0,"} {
+		if _, err := parseIntensities(in); err == nil {
+			t.Errorf("parseIntensities(%q): expected an error, got none", in)
+		}
+	}
+}
+
+func TestParseNanometers(t *testing.T) {
+	tests := []struct {
+		in string
+		nm int
+		ok bool
+	}{
+		{in: "450nm", nm: 450, ok: true},
+		{in: "660nm", nm: 660, ok: true},
+		{in: "5700K", ok: false},
+		{in: "nm", ok: false},
+		{in: "", ok: false},
+	}
+	for _, tt := range tests {
+		nm, ok := parseNanometers(tt.in)
+		if nm != tt.nm || ok != tt.ok {
+			t.Errorf("parseNanometers(%q): expected (%d, %t), got (%d, %t)", tt.in, tt.nm, tt.ok, nm, ok)
+		}
+	}
+}
+
+func TestParseUptime(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected time.Duration
+	}{
+		{in: "0d 02h 39m 37s", expected: 2*time.Hour + 39*time.Minute + 37*time.Second},
+		{in: "0d 02h 10m 08s", expected: 2*time.Hour + 10*time.Minute + 8*time.Second},
+		{in: "3d 4h 5m 6s", expected: 76*time.Hour + 5*time.Minute + 6*time.Second},
+		{in: "12m 0s", expected: 12 * time.Minute},
+		{in: "412d 00h 00m 01s", expected: 412*24*time.Hour + time.Second},
+	}
+	for _, tt := range tests {
+		got, err := ParseUptime(tt.in)
+		if err != nil {
+			t.Errorf("ParseUptime(%q): unexpected error: %s", tt.in, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("ParseUptime(%q): expected %s, got %s", tt.in, tt.expected, got)
+		}
+	}
+
+	for _, in := range []string{"", "0d 02x", "02h 0d", "d", "-1d", "1d 1d"} {
+		if _, err := ParseUptime(in); err == nil {
+			t.Errorf("ParseUptime(%q): expected an error, got none", in)
+		}
+	}
+}
+
+func TestParseDeviceClock(t *testing.T) {
+	loc := time.FixedZone("test", -5*60*60)
+	got, err := ParseDeviceClock("2017:03:17:02:48:41", loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := time.Date(2017, 3, 17, 2, 48, 41, 0, loc); !got.Equal(expected) {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+	if _, err := ParseDeviceClock("2017-03-17 02:48:41", loc); err == nil {
+		t.Errorf("expected an error for a malformed clock, got none")
+	}
+
+	if formatted := FormatDeviceClock(got); formatted != "2017:03:17:02:48:41" {
+		t.Errorf("expected the clock to round trip, got %q", formatted)
+	}
+	// the same instant formatted for a device in another zone
+	if formatted := FormatDeviceClock(got.In(time.UTC)); formatted != "2017:03:17:07:48:41" {
+		t.Errorf("expected 2017:03:17:07:48:41 in UTC, got %q", formatted)
+	}
+
+	local, err := ParseDeviceClock("2017:03:17:02:48:41", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if local.Location() != time.Local {
+		t.Errorf("expected a nil location to default to time.Local, got %s", local.Location())
+	}
+}
+
+func TestParseNTPOffset(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected time.Duration
+	}{
+		{in: "00:00:00", expected: 0},
+		{in: "01:00:00", expected: time.Hour},
+		{in: "+05:30:00", expected: 5*time.Hour + 30*time.Minute},
+		{in: "-08:00:00", expected: -8 * time.Hour},
+		{in: "-00:00:30", expected: -30 * time.Second},
+	}
+	for _, tt := range tests {
+		got, err := parseNTPOffset(tt.in)
+		if err != nil {
+			t.Errorf("parseNTPOffset(%q): unexpected error: %s", tt.in, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("parseNTPOffset(%q): expected %s, got %s", tt.in, tt.expected, got)
+		}
+		if formatted := FormatNTPOffset(got); strings.TrimPrefix(tt.in, "+") != formatted {
+			t.Errorf("FormatNTPOffset(%s): expected %q, got %q", got, strings.TrimPrefix(tt.in, "+"), formatted)
+		}
+	}
+
+	for _, in := range []string{"", "01:00", "01:60:00", "aa:00:00", "--01:00:00"} {
+		if _, err := parseNTPOffset(in); err == nil {
+			t.Errorf("parseNTPOffset(%q): expected an error, got none", in)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package heliospectra
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// deviceJSON is the persisted form of a Device.
+type deviceJSON struct {
+	IP     string `json:"ip"`
+	Port   int    `json:"port,omitempty"`
+	Scheme string `json:"scheme,omitempty"`
+	MAC    string `json:"mac,omitempty"`
+	Serial string `json:"serial,omitempty"`
+}
+
+// MarshalJSON encodes the Device's address and identity: its IP address, HTTP
+// port and scheme, MAC address and serial number. The HTTP client and the
+// exported options aren't included. It's an error if the Device has no IP
+// address, since UnmarshalJSON couldn't restore it.
+func (d *Device) MarshalJSON() ([]byte, error) {
+	if d.addr == nil {
+		return nil, fmt.Errorf("device %s has no IP address", d.serial)
+	}
+	dj := deviceJSON{
+		IP:     d.addr.String(),
+		Port:   d.port,
+		Scheme: d.scheme,
+		Serial: d.serial,
+	}
+	if d.mac != nil {
+		dj.MAC = d.mac.String()
+	}
+	return json.Marshal(dj)
+}
+
+// UnmarshalJSON decodes a Device encoded by MarshalJSON, so that known fixtures
+// can be restored without rescanning. The Device keeps its HTTP client, or
+// uses http.DefaultClient if it has none. If the Device was already in use at
+// a different address or with a different identity, what it has cached from
+// that fixture is discarded.
+func (d *Device) UnmarshalJSON(data []byte) error {
+	var dj deviceJSON
+	if err := json.Unmarshal(data, &dj); err != nil {
+		return err
+	}
+	addr := net.ParseIP(dj.IP)
+	if addr == nil {
+		return fmt.Errorf("invalid device IP address %q", dj.IP)
+	}
+	var mac net.HardwareAddr
+	if dj.MAC != "" {
+		var err error
+		if mac, err = net.ParseMAC(dj.MAC); err != nil {
+			return err
+		}
+	}
+
+	if !addr.Equal(d.addr) || dj.Port != d.port || dj.Scheme != d.scheme ||
+		!bytes.Equal(mac, d.mac) || dj.Serial != d.serial {
+		d.mu.Lock()
+		d.diag = nil
+		d.channels = 0
+		d.mu.Unlock()
+	}
+	d.addr = addr
+	d.port = dj.Port
+	d.scheme = dj.Scheme
+	d.mac = mac
+	d.serial = dj.Serial
+	if d.client == nil {
+		d.client = http.DefaultClient
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package heliospectra
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestDevice_JSON(t *testing.T) {
+	mac, err := net.ParseMAC("64:1a:10:10:10:10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	device := NewDevice(net.IPv4(192, 168, 1, 8), nil)
+	device.port = 8080
+	device.scheme = "https"
+	device.mac = mac
+	device.serial = "abc123"
+
+	data, err := json.Marshal(device)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedJSON := `{"ip":"192.168.1.8","port":8080,"scheme":"https","mac":"64:1a:10:10:10:10","serial":"abc123"}`
+	if string(data) != expectedJSON {
+		t.Errorf("expected JSON %s, got %s", expectedJSON, data)
+	}
+
+	var restored Device
+	if err := json.Unmarshal(data, &restored); err != nil {
+		t.Fatal(err)
+	}
+	if !restored.addr.Equal(device.addr) {
+		t.Errorf("expected addr %s, got %s", device.addr, restored.addr)
+	}
+	if restored.port != 8080 || restored.scheme != "https" || restored.serial != "abc123" {
+		t.Errorf("expected port, scheme and serial to be restored, got %d, %q, %q", restored.port, restored.scheme, restored.serial)
+	}
+	if !reflect.DeepEqual(mac, restored.mac) {
+		t.Errorf("expected mac %s, got %s", mac, restored.mac)
+	}
+	if restored.client != http.DefaultClient {
+		t.Errorf("expected the restored Device to use http.DefaultClient")
+	}
+
+	if err := json.Unmarshal([]byte(`{"ip":"not-an-ip"}`), &restored); err == nil {
+		t.Errorf("expected an error for an invalid IP, got none")
+	}
+}
+
+func TestDevice_JSON_NoAddr(t *testing.T) {
+	device := NewDevice(nil, nil)
+	if data, err := json.Marshal(device); err == nil {
+		t.Errorf("expected an error marshaling a Device without an IP address, got %s", data)
+	}
+}
+
+func TestDevice_UnmarshalJSON_ClearsCache(t *testing.T) {
+	device := NewDevice(net.IPv4(192, 168, 1, 8), nil)
+	device.diag = &Diagnostic{Model: "L4"}
+	device.channels = 4
+
+	// restoring the same fixture keeps what's cached from it
+	if err := json.Unmarshal([]byte(`{"ip":"192.168.1.8"}`), device); err != nil {
+		t.Fatal(err)
+	}
+	if device.cachedDiagnostic() == nil || device.channels != 4 {
+		t.Errorf("expected the cache to be kept for the same fixture, got diag=%v channels=%d", device.cachedDiagnostic(), device.channels)
+	}
+
+	if err := json.Unmarshal([]byte(`{"ip":"192.168.1.9"}`), device); err != nil {
+		t.Fatal(err)
+	}
+	if device.cachedDiagnostic() != nil || device.channels != 0 {
+		t.Errorf("expected the cache to be cleared for another fixture, got diag=%v channels=%d", device.cachedDiagnostic(), device.channels)
+	}
+}
@@ -0,0 +1,83 @@
+package heliospectra
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+)
+
+// ProbeResult describes what answered at a Device's address.
+type ProbeResult struct {
+	// Reachable is true if a server accepted a connection at the address,
+	// even if it then failed to answer HTTP.
+	Reachable bool
+	// Heliospectra is true if the server is a Heliospectra fixture.
+	Heliospectra bool
+	// Model and Firmware are the fixture's model and CPU firmware version.
+	Model    string
+	Firmware string
+	// Diagnostic is the fixture's diagnostic, if it's a fixture.
+	Diagnostic *Diagnostic
+	// Err is the reason the address isn't a reachable fixture, if it isn't.
+	Err error
+}
+
+// Probe classifies whatever is at the Device's address: nothing reachable, an
+// HTTP server that isn't a Heliospectra fixture, or a fixture along with its
+// model and firmware. It's intended for sweeping an address range, so neither
+// an unreachable host nor a foreign server is an error; the reason is in the
+// result's Err instead. An error is only returned if ctx is done.
+func (d *Device) Probe(ctx context.Context) (*ProbeResult, error) {
+	body, err := d.get(ctx, d.Endpoints.diagnostic(), nil)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		if !connected(err) {
+			return &ProbeResult{Err: err}, nil
+		}
+		// something answered, just not with a diagnostic
+		return &ProbeResult{Reachable: true, Err: err}, nil
+	}
+
+	diag := &Diagnostic{}
+	if err := xml.Unmarshal(body, diag); err != nil {
+		return &ProbeResult{Reachable: true, Err: fmt.Errorf("response isn't a diagnostic: %w", err)}, nil
+	}
+	if diag.Model == "" || len(diag.Wavelengths) == 0 {
+		return &ProbeResult{Reachable: true, Err: errors.New("response has no model or wavelengths")}, nil
+	}
+	return &ProbeResult{
+		Reachable:    true,
+		Heliospectra: true,
+		Model:        diag.Model,
+		Firmware:     diag.CPUFW,
+		Diagnostic:   diag,
+	}, nil
+}
+
+// connected reports whether a request that failed with err got as far as
+// connecting to a server. Only failures to dial, including dial timeouts, mean
+// that nothing is there; a server that accepted the connection and then failed
+// the TLS handshake, hung up or sent a malformed response is still a server.
+func connected(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return false
+	}
+	return !errors.Is(err, syscall.ECONNREFUSED) &&
+		!errors.Is(err, syscall.EHOSTUNREACH) &&
+		!errors.Is(err, syscall.ENETUNREACH)
+}
+
+// Ping is a cheap liveness check for monitoring. It fetches the Device's status
+// without parsing it and returns nil if the Device answers with a 2xx status,
+// an *HTTPStatusError if it answers with any other status, or the request's
+// error if it doesn't answer at all.
+func (d *Device) Ping(ctx context.Context) error {
+	_, err := d.get(ctx, d.Endpoints.status(), nil)
+	return err
+}
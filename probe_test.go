@@ -0,0 +1,131 @@
+package heliospectra
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDevice_Probe(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tests := []struct {
+		name         string
+		handler      http.HandlerFunc
+		reachable    bool
+		heliospectra bool
+	}{
+		{
+			name:         "fixture",
+			handler:      func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(diagResponse)) },
+			reachable:    true,
+			heliospectra: true,
+		},
+		{
+			name:      "not found",
+			handler:   http.NotFound,
+			reachable: true,
+		},
+		{
+			name: "web page",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html")
+				w.Write([]byte("<html><body>Printer status</body></html>"))
+			},
+			reachable: true,
+		},
+		{
+			name:      "json",
+			handler:   func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(`{"some":"json"}`)) },
+			reachable: true,
+		},
+		{
+			name:      "hang up",
+			handler:   hijack(t, nil),
+			reachable: true,
+		},
+		{
+			name:      "malformed response",
+			handler:   hijack(t, []byte("SSH-2.0-OpenSSH_8.9\r\n")),
+			reachable: true,
+		},
+	}
+	for _, tt := range tests {
+		device, server := newTestDevice(t, tt.handler)
+		result, err := device.Probe(ctx)
+		server.Close()
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", tt.name, err)
+			continue
+		}
+		if result.Reachable != tt.reachable || result.Heliospectra != tt.heliospectra {
+			t.Errorf("%s: expected reachable=%t heliospectra=%t, got %+v", tt.name, tt.reachable, tt.heliospectra, result)
+		}
+		if tt.heliospectra {
+			if result.Model != "L4" || result.Firmware == "" || result.Diagnostic == nil || result.Err != nil {
+				t.Errorf("%s: expected the fixture's model and firmware, got %+v", tt.name, result)
+			}
+		} else if result.Err == nil {
+			t.Errorf("%s: expected a reason the address isn't a fixture", tt.name)
+		}
+	}
+
+	// an address with nothing listening
+	server := httptest.NewServer(http.NotFoundHandler())
+	port := serverPort(t, server)
+	server.Close()
+	device := NewDeviceWithOptions(net.IPv4(127, 0, 0, 1), WithHTTPPort(port))
+	result, err := device.Probe(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Reachable || result.Heliospectra || result.Err == nil {
+		t.Errorf("expected an unreachable result with a reason, got %+v", result)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := device.Probe(cancelled); err == nil {
+		t.Errorf("expected an error from a cancelled probe, got none")
+	}
+}
+
+// hijack returns a handler that takes over the connection, writes raw to it,
+// and closes it without an HTTP response.
+func hijack(t *testing.T, raw []byte) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		conn.Write(raw)
+	}
+}
+
+func TestDevice_Ping(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	statusToReturn := 200
+	device, server := newTestDevice(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/status.xml" {
+			t.Errorf("expected URL /status.xml, got %s", r.URL.Path)
+		}
+		w.WriteHeader(statusToReturn)
+		w.Write([]byte(statusResponse))
+	})
+	defer server.Close()
+
+	if err := device.Ping(ctx); err != nil {
+		t.Errorf("expected no error, got %s", err)
+	}
+
+	statusToReturn = 500
+	assertHTTPStatusError(t, device.Ping(ctx), 500, "status.xml")
+}
@@ -0,0 +1,101 @@
+package heliospectra
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// SelfTestIntensity is the intensity each channel is briefly set to by
+// SelfTest.
+const SelfTestIntensity = 50
+
+// selfTestRestoreTimeout is how long SelfTest allows for restoring the
+// original intensities, which doesn't depend on the test's own context.
+const selfTestRestoreTimeout = 5 * time.Second
+
+// SelfTestReport is the result of a SelfTest.
+type SelfTestReport struct {
+	// Original is the intensities the fixture had before the test, which it
+	// was restored to afterwards.
+	Original []int
+	Channels []SelfTestResult
+}
+
+// SelfTestResult is the result of testing a single channel.
+type SelfTestResult struct {
+	Channel int
+	// Passed is true if the channel reported SelfTestIntensity after being
+	// set to it.
+	Passed bool
+	// Err is the error, if any, from setting or reading back the channel.
+	Err error
+}
+
+// Passed reports whether every channel passed.
+func (r *SelfTestReport) Passed() bool {
+	for _, c := range r.Channels {
+		if !c.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// SelfTest turns on each channel of the fixture in turn at SelfTestIntensity,
+// with the others off, and reads back its status to confirm the channel
+// responded. The original intensities are restored afterwards. It's intended
+// as an acceptance test when commissioning a fixture.
+//
+// Failing channels are reported in the SelfTestReport rather than as an error.
+// An error is returned if the original intensities can't be read or restored.
+// If ctx is done partway through, the remaining channels are skipped, the
+// original intensities are still restored, and ctx's error is returned with
+// the partial report.
+func (d *Device) SelfTest(ctx context.Context) (*SelfTestReport, error) {
+	original, err := d.GetIntensities(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &SelfTestReport{Original: original}
+	for i := range original {
+		if ctx.Err() != nil {
+			break
+		}
+		report.Channels = append(report.Channels, d.selfTestChannel(ctx, i, len(original)))
+	}
+
+	// Restore even if ctx is done, so the fixture isn't left at the test
+	// pattern.
+	restoreCtx, cancel := context.WithTimeout(context.Background(), selfTestRestoreTimeout)
+	defer cancel()
+	if tag, ok := RequestTag(ctx); ok {
+		restoreCtx = WithRequestTag(restoreCtx, tag)
+	}
+	if err := d.SetIntensities(restoreCtx, original...); err != nil {
+		return report, fmt.Errorf("restoring intensities %v: %w", original, err)
+	}
+	return report, ctx.Err()
+}
+
+func (d *Device) selfTestChannel(ctx context.Context, channel, count int) SelfTestResult {
+	result := SelfTestResult{Channel: channel}
+	intensities := make([]int, count)
+	intensities[channel] = SelfTestIntensity
+	if err := d.SetIntensities(ctx, intensities...); err != nil {
+		result.Err = err
+		return result
+	}
+	got, err := d.GetIntensities(ctx)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	if len(got) != count {
+		result.Err = fmt.Errorf("status has %d intensities, expected %d", len(got), count)
+		return result
+	}
+	result.Passed = got[channel] == SelfTestIntensity
+	return result
+}
@@ -0,0 +1,94 @@
+package heliospectra
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDevice_SelfTest(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// a mock fixture whose channel 2 doesn't respond
+	current := []string{"100", "200", "300", "400"}
+	var sets []string
+	device, server := newTestDevice(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/intensity.cgi":
+			value := r.URL.Query().Get("int")
+			sets = append(sets, value)
+			for i, v := range strings.Split(value, ":") {
+				if i != 2 {
+					current[i] = v
+				}
+			}
+		case "/status.xml":
+			pairs := make([]string, len(current))
+			for i, v := range current {
+				pairs[i] = strconv.Itoa(i) + ":" + v
+			}
+			w.Write([]byte(strings.Replace(statusResponse, "0:0,1:0,2:0,3:0,", strings.Join(pairs, ","), 1)))
+		}
+	})
+	defer server.Close()
+
+	report, err := device.SelfTest(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedSets := []string{"50:0:0:0", "0:50:0:0", "0:0:50:0", "0:0:0:50", "100:200:300:400"}
+	if !reflect.DeepEqual(expectedSets, sets) {
+		t.Errorf("expected intensities to be set to %v, got %v", expectedSets, sets)
+	}
+	if expected := []int{100, 200, 300, 400}; !reflect.DeepEqual(expected, report.Original) {
+		t.Errorf("expected original intensities %v, got %v", expected, report.Original)
+	}
+	if len(report.Channels) != 4 {
+		t.Fatalf("expected 4 channel results, got %d", len(report.Channels))
+	}
+	for _, c := range report.Channels {
+		if expected := c.Channel != 2; c.Passed != expected {
+			t.Errorf("channel %d: expected passed=%t, got %t (err=%v)", c.Channel, expected, c.Passed, c.Err)
+		}
+	}
+	if report.Passed() {
+		t.Errorf("expected the report not to pass with a failed channel")
+	}
+}
+
+func TestDevice_SelfTest_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var sets []string
+	device, server := newTestDevice(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/intensity.cgi":
+			sets = append(sets, r.URL.Query().Get("int"))
+			if len(sets) == 2 {
+				cancel() // mid-test, with channel 1 lit
+			}
+		case "/status.xml":
+			w.Write([]byte(strings.Replace(statusResponse, "0:0,1:0,2:0,3:0,", "0:100,1:200,2:300,3:400,", 1)))
+		}
+	})
+	defer server.Close()
+
+	report, err := device.SelfTest(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if report == nil || len(report.Channels) == 4 {
+		t.Errorf("expected a partial report, got %+v", report)
+	}
+	if len(sets) == 0 || sets[len(sets)-1] != "100:200:300:400" {
+		t.Errorf("expected the original intensities to be restored last, got %v", sets)
+	}
+}
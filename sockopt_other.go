@@ -0,0 +1,8 @@
+//go:build !unix && !windows
+
+package heliospectra
+
+// setBroadcast is a no-op on platforms without socket options.
+func setBroadcast(fd uintptr) error {
+	return nil
+}
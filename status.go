@@ -0,0 +1,65 @@
+package heliospectra
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ActiveScheduleName reports whether a schedule is running on the fixture and,
+// if the firmware reports one, the running schedule's name. OnSchedule is
+// "Not running" when no schedule is active and "Running" for an unnamed one;
+// any other value, optionally prefixed by "Running:" or "Running -", is taken
+// to be the name of the running schedule.
+func (s *Status) ActiveScheduleName() (string, bool) {
+	value := strings.TrimSpace(s.OnSchedule)
+	switch {
+	case value == "", strings.EqualFold(value, "Not running"):
+		return "", false
+	case len(value) >= len("Running") && strings.EqualFold(value[:len("Running")], "Running"):
+		name := strings.TrimSpace(value[len("Running"):])
+		if name != "" && name[0] != ':' && name[0] != '-' {
+			// a name that just happens to start with "Running"
+			return value, true
+		}
+		return strings.TrimSpace(strings.TrimLeft(name, ":-")), true
+	default:
+		return value, true
+	}
+}
+
+// ParsedIntensities parses the status's Intensities field into a slice of
+// intensities ordered by wavelength number.
+func (s *Status) ParsedIntensities() ([]int, error) {
+	return parseIntensities(s.Intensities)
+}
+
+// ParsedPowerDraw parses the fixture's current draw, such as "0.0A,0.0W", into
+// amps and watts.
+func (s *Status) ParsedPowerDraw() (amps float64, watts float64, err error) {
+	parts := strings.Split(strings.TrimSpace(s.PowerDraw), ",")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid power draw %q", s.PowerDraw)
+	}
+	amps, err = parseUnitFloat(parts[0], "A")
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid power draw %q", s.PowerDraw)
+	}
+	watts, err = parseUnitFloat(parts[1], "W")
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid power draw %q", s.PowerDraw)
+	}
+	return amps, watts, nil
+}
+
+// ParsedUptime parses the status's Uptime, the time since the fixture last
+// started.
+func (s *Status) ParsedUptime() (time.Duration, error) {
+	return ParseUptime(s.Uptime)
+}
+
+// ParsedInternalTime parses the status's InternalTime, the device clock, in
+// the device's time zone loc, or in time.Local if loc is nil.
+func (s *Status) ParsedInternalTime(loc *time.Location) (time.Time, error) {
+	return ParseDeviceClock(s.InternalTime, loc)
+}
@@ -0,0 +1,126 @@
+package heliospectra
+
+import (
+	"encoding/xml"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStatus_ActiveScheduleName(t *testing.T) {
+	tests := []struct {
+		onSchedule string
+		name       string
+		running    bool
+	}{
+		{onSchedule: "Not running", name: "", running: false},
+		{onSchedule: "", name: "", running: false},
+		{onSchedule: "Running", name: "", running: true},
+		{onSchedule: "Running: Veg week 2", name: "Veg week 2", running: true},
+		{onSchedule: "Running - Flower", name: "Flower", running: true},
+		{onSchedule: "Flower", name: "Flower", running: true},
+		{onSchedule: "Running late", name: "Running late", running: true},
+	}
+	for _, tt := range tests {
+		status := &Status{OnSchedule: tt.onSchedule}
+		name, running := status.ActiveScheduleName()
+		if name != tt.name || running != tt.running {
+			t.Errorf("ActiveScheduleName() for %q: expected (%q, %t), got (%q, %t)", tt.onSchedule, tt.name, tt.running, name, running)
+		}
+	}
+}
+
+func TestStatus_ParsedIntensities(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected []int
+		wantErr  bool
+	}{
+		{in: "0:0,1:0,2:0,3:0,", expected: []int{0, 0, 0, 0}},
+		{in: "0:1000,1:250,2:0", expected: []int{1000, 250, 0}},
+		{in: "  ", expected: []int{}},
+		{in: "1:0,0:0,", wantErr: true},
+		{in: "0:0,1:high,", wantErr: true},
+	}
+	for _, tt := range tests {
+		status := &Status{Intensities: tt.in}
+		got, err := status.ParsedIntensities()
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParsedIntensities() for %q: expected an error, got none", tt.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParsedIntensities() for %q: unexpected error: %s", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(tt.expected, got) {
+			t.Errorf("ParsedIntensities() for %q: expected %v, got %v", tt.in, tt.expected, got)
+		}
+	}
+}
+
+func TestStatus_ParsedPowerDraw(t *testing.T) {
+	status := &Status{}
+	if err := xml.Unmarshal([]byte(statusResponse), status); err != nil {
+		t.Fatal(err)
+	}
+	amps, watts, err := status.ParsedPowerDraw()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amps != 0 || watts != 0 {
+		t.Errorf("expected 0A, 0W from the fixture, got %vA, %vW", amps, watts)
+	}
+
+	status.PowerDraw = "1.5A,345.2W"
+	amps, watts, err = status.ParsedPowerDraw()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amps != 1.5 || watts != 345.2 {
+		t.Errorf("expected 1.5A, 345.2W, got %vA, %vW", amps, watts)
+	}
+
+	for _, in := range []string{"", "1.5A", "1.5W,345.2A", "xA,1W"} {
+		status.PowerDraw = in
+		if _, _, err := status.ParsedPowerDraw(); err == nil {
+			t.Errorf("ParsedPowerDraw() for %q: expected an error, got none", in)
+		}
+	}
+}
+
+func TestStatus_ParsedUptime(t *testing.T) {
+	status := &Status{}
+	if err := xml.Unmarshal([]byte(statusResponse), status); err != nil {
+		t.Fatal(err)
+	}
+	uptime, err := status.ParsedUptime()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := 2*time.Hour + 39*time.Minute + 37*time.Second; uptime != expected {
+		t.Errorf("expected uptime %s, got %s", expected, uptime)
+	}
+
+	status.Uptime = "123d 1h 2m 3s"
+	uptime, err = status.ParsedUptime()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := 123*24*time.Hour + time.Hour + 2*time.Minute + 3*time.Second; uptime != expected {
+		t.Errorf("expected uptime %s, got %s", expected, uptime)
+	}
+}
+
+func TestStatus_ParsedInternalTime(t *testing.T) {
+	status := &Status{InternalTime: "2017:03:17:19:07:56"}
+	got, err := status.ParsedInternalTime(time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := time.Date(2017, 3, 17, 19, 7, 56, 0, time.UTC); !got.Equal(expected) {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+}
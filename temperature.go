@@ -0,0 +1,147 @@
+package heliospectra
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// TempUnit is the unit a fixture reports temperatures in.
+type TempUnit string
+
+// The temperature units a fixture can be set to.
+const (
+	TempUnitCelsius    TempUnit = "C"
+	TempUnitFahrenheit TempUnit = "F"
+)
+
+// ParseTempUnit parses a temperature unit such as the "C" or "F" reported in
+// Diagnostic.TempUnit.
+func ParseTempUnit(s string) (TempUnit, error) {
+	switch u := TempUnit(strings.ToUpper(strings.TrimSpace(s))); u {
+	case TempUnitCelsius, TempUnitFahrenheit:
+		return u, nil
+	default:
+		return "", fmt.Errorf("unknown temperature unit %q", s)
+	}
+}
+
+// Convert converts value from this unit to degrees Celsius. Values in an
+// unknown unit are returned unchanged.
+func (u TempUnit) Convert(value float64) float64 {
+	if u == TempUnitFahrenheit {
+		return (value - 32) * 5 / 9
+	}
+	return value
+}
+
+// ParsedTempUnit parses the diagnostic's TempUnit field.
+func (d *Diagnostic) ParsedTempUnit() (TempUnit, error) {
+	return ParseTempUnit(d.TempUnit)
+}
+
+// Temperature is a reading from one of a fixture's temperature sensors.
+type Temperature struct {
+	Sensor int
+	Value  float64
+	// Unit is 'C' or 'F', depending on the fixture's TempUnit setting.
+	Unit rune
+}
+
+// TempUnit returns the unit of the reading.
+func (t Temperature) TempUnit() TempUnit {
+	return TempUnit(string(t.Unit))
+}
+
+// Celsius returns the reading in degrees Celsius.
+func (t Temperature) Celsius() float64 {
+	return t.TempUnit().Convert(t.Value)
+}
+
+func (t Temperature) String() string {
+	return fmt.Sprintf("%.1f%c", t.Value, t.Unit)
+}
+
+// ParseTemps parses temperature readings such as "0:26.8C," or
+// "0:26.8C,1:31.0C," as reported in Diagnostic.Temps and Status.Temp.
+func ParseTemps(s string) ([]Temperature, error) {
+	values, err := splitIndexed(s)
+	if err != nil {
+		return nil, err
+	}
+	temps := make([]Temperature, len(values))
+	for i, v := range values {
+		if v == "" {
+			return nil, fmt.Errorf("invalid temperature %q at sensor %d", v, i)
+		}
+		unit, err := ParseTempUnit(v[len(v)-1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid temperature unit in %q at sensor %d", v, i)
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(v[:len(v)-1]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid temperature %q at sensor %d", v, i)
+		}
+		temps[i] = Temperature{Sensor: i, Value: value, Unit: rune(unit[0])}
+	}
+	return temps, nil
+}
+
+// ParsedTemps parses the diagnostic's Temps field.
+func (d *Diagnostic) ParsedTemps() ([]Temperature, error) {
+	return ParseTemps(d.Temps)
+}
+
+// ParsedTemps parses the status's Temp field.
+func (s *Status) ParsedTemps() ([]Temperature, error) {
+	return ParseTemps(s.Temp)
+}
+
+// AllowedTemp is the temperature range a fixture is allowed to operate in, as
+// reported in Diagnostic.AllowedTemp.
+type AllowedTemp struct {
+	MinCelsius, MaxCelsius       float64
+	MinFahrenheit, MaxFahrenheit float64
+}
+
+// ParseAllowedTemp parses an allowed temperature range such as
+// "15.0 60.0:59.0 140.0", as reported in Diagnostic.AllowedTemp. The range is
+// given twice, separated by a colon: first as the minimum and maximum in
+// degrees Celsius, then the same minimum and maximum in degrees Fahrenheit.
+func ParseAllowedTemp(s string) (AllowedTemp, error) {
+	ranges := strings.SplitN(s, ":", 2)
+	if len(ranges) != 2 {
+		return AllowedTemp{}, fmt.Errorf("invalid allowed temperature %q", s)
+	}
+	var at AllowedTemp
+	var err error
+	if at.MinCelsius, at.MaxCelsius, err = parseTempRange(ranges[0]); err != nil {
+		return AllowedTemp{}, fmt.Errorf("invalid allowed temperature %q: %s", s, err)
+	}
+	if at.MinFahrenheit, at.MaxFahrenheit, err = parseTempRange(ranges[1]); err != nil {
+		return AllowedTemp{}, fmt.Errorf("invalid allowed temperature %q: %s", s, err)
+	}
+	return at, nil
+}
+
+func parseTempRange(s string) (min, max float64, err error) {
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("expected a minimum and maximum, got %q", s)
+	}
+	if min, err = strconv.ParseFloat(fields[0], 64); err != nil {
+		return 0, 0, fmt.Errorf("invalid minimum %q", fields[0])
+	}
+	if max, err = strconv.ParseFloat(fields[1], 64); err != nil {
+		return 0, 0, fmt.Errorf("invalid maximum %q", fields[1])
+	}
+	if min > max {
+		return 0, 0, fmt.Errorf("minimum %v above maximum %v", min, max)
+	}
+	return min, max, nil
+}
+
+// ParsedAllowedTemp parses the diagnostic's AllowedTemp field.
+func (d *Diagnostic) ParsedAllowedTemp() (AllowedTemp, error) {
+	return ParseAllowedTemp(d.AllowedTemp)
+}
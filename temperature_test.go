@@ -0,0 +1,139 @@
+package heliospectra
+
+import (
+	"encoding/xml"
+	"reflect"
+	"testing"
+)
+
+func TestParseTemps(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected []Temperature
+	}{
+		{in: "0:26.8C,", expected: []Temperature{{Sensor: 0, Value: 26.8, Unit: 'C'}}},
+		{in: "0:26.8C,1:31.0C,2:-4.5C", expected: []Temperature{
+			{Sensor: 0, Value: 26.8, Unit: 'C'},
+			{Sensor: 1, Value: 31.0, Unit: 'C'},
+			{Sensor: 2, Value: -4.5, Unit: 'C'},
+		}},
+		{in: "0:80.2F,1:88F,", expected: []Temperature{
+			{Sensor: 0, Value: 80.2, Unit: 'F'},
+			{Sensor: 1, Value: 88, Unit: 'F'},
+		}},
+		{in: "0:26.8c,", expected: []Temperature{{Sensor: 0, Value: 26.8, Unit: 'C'}}},
+		{in: "", expected: []Temperature{}},
+	}
+	for _, tt := range tests {
+		got, err := ParseTemps(tt.in)
+		if err != nil {
+			t.Errorf("ParseTemps(%q): unexpected error: %s", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(tt.expected, got) {
+			t.Errorf("ParseTemps(%q): expected %v, got %v", tt.in, tt.expected, got)
+		}
+	}
+
+	for _, in := range []string{"0:26.8", "0:26.8K,", "0:C,", "0:,", "1:26.8C,", "0:hotC"} {
+		if _, err := ParseTemps(in); err == nil {
+			t.Errorf("ParseTemps(%q): expected an error, got none", in)
+		}
+	}
+}
+
+func TestStatus_ParsedTemps(t *testing.T) {
+	status := &Status{Temp: "0:26.0C,"}
+	temps, err := status.ParsedTemps()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []Temperature{{Sensor: 0, Value: 26, Unit: 'C'}}; !reflect.DeepEqual(expected, temps) {
+		t.Errorf("expected %v, got %v", expected, temps)
+	}
+	if s := temps[0].String(); s != "26.0C" {
+		t.Errorf("expected String()=26.0C, got %s", s)
+	}
+	if unit := temps[0].TempUnit(); unit != TempUnitCelsius {
+		t.Errorf("expected TempUnit()=%s, got %s", TempUnitCelsius, unit)
+	}
+}
+
+func TestTempUnit(t *testing.T) {
+	tests := []struct {
+		in       string
+		unit     TempUnit
+		value    float64
+		expected float64
+	}{
+		{in: "C", unit: TempUnitCelsius, value: 26.5, expected: 26.5},
+		{in: "F", unit: TempUnitFahrenheit, value: 212, expected: 100},
+		{in: " f ", unit: TempUnitFahrenheit, value: 32, expected: 0},
+	}
+	for _, tt := range tests {
+		unit, err := ParseTempUnit(tt.in)
+		if err != nil {
+			t.Errorf("ParseTempUnit(%q): unexpected error: %s", tt.in, err)
+			continue
+		}
+		if unit != tt.unit {
+			t.Errorf("ParseTempUnit(%q): expected %q, got %q", tt.in, tt.unit, unit)
+		}
+		if got := unit.Convert(tt.value); got != tt.expected {
+			t.Errorf("%q.Convert(%v): expected %v, got %v", unit, tt.value, tt.expected, got)
+		}
+	}
+
+	for _, in := range []string{"K", "", "Celsius"} {
+		if _, err := ParseTempUnit(in); err == nil {
+			t.Errorf("ParseTempUnit(%q): expected an error, got none", in)
+		}
+	}
+	if got := TempUnit("K").Convert(300); got != 300 {
+		t.Errorf("expected an unknown unit to leave the value unchanged, got %v", got)
+	}
+
+	if c := (Temperature{Value: 80.6, Unit: 'F'}).Celsius(); c < 26.99 || c > 27.01 {
+		t.Errorf("expected 80.6F to be 27C, got %v", c)
+	}
+}
+
+func TestParseAllowedTemp(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected AllowedTemp
+	}{
+		{in: "15.0 60.0:59.0 140.0", expected: AllowedTemp{MinCelsius: 15, MaxCelsius: 60, MinFahrenheit: 59, MaxFahrenheit: 140}},
+		{in: " -5 40 : 23 104 ", expected: AllowedTemp{MinCelsius: -5, MaxCelsius: 40, MinFahrenheit: 23, MaxFahrenheit: 104}},
+	}
+	for _, tt := range tests {
+		got, err := ParseAllowedTemp(tt.in)
+		if err != nil {
+			t.Errorf("ParseAllowedTemp(%q): unexpected error: %s", tt.in, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("ParseAllowedTemp(%q): expected %+v, got %+v", tt.in, tt.expected, got)
+		}
+	}
+
+	for _, in := range []string{"", "15.0 60.0", "15.0:59.0", "15.0 60.0:59.0", "15.0 60.0 70.0:59.0 140.0", "cold 60.0:59.0 140.0", "15.0 60.0:59.0 hot", "60.0 15.0:140.0 59.0"} {
+		if _, err := ParseAllowedTemp(in); err == nil {
+			t.Errorf("ParseAllowedTemp(%q): expected an error, got none", in)
+		}
+	}
+}
+
+func TestDiagnostic_ParsedAllowedTemp(t *testing.T) {
+	var diag Diagnostic
+	if err := xml.Unmarshal([]byte(diagResponse), &diag); err != nil {
+		t.Fatal(err)
+	}
+	at, err := diag.ParsedAllowedTemp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := (AllowedTemp{MinCelsius: 15, MaxCelsius: 60, MinFahrenheit: 59, MaxFahrenheit: 140}); at != expected {
+		t.Errorf("expected %+v, got %+v", expected, at)
+	}
+}
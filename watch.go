@@ -0,0 +1,100 @@
+package heliospectra
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// WatchIntensities polls the Device's status every interval and sends its
+// intensities on the returned channel: first the initial intensities, then
+// only when they change from the last observed value. Errors while polling are
+// logged to the Device's Logger and polling continues. The channel is closed
+// once ctx is done.
+func (d *Device) WatchIntensities(ctx context.Context, interval time.Duration) (<-chan []int, error) {
+	if interval <= 0 {
+		return nil, errors.New("interval must be positive")
+	}
+	ctx, cancel, err := d.backgroundContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan []int)
+	go func() {
+		defer cancel()
+		d.watchIntensities(ctx, interval, ch)
+	}()
+	return ch, nil
+}
+
+// Close stops any background work started from this Device, such as
+// WatchIntensities, and causes future calls that start background work to
+// fail. It's safe to call Close more than once. Close doesn't affect the
+// Device's http.Client, which may be shared.
+func (d *Device) Close() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return
+	}
+	d.closed = true
+	if d.done != nil {
+		close(d.done)
+	}
+}
+
+// backgroundContext returns a context derived from ctx which is also cancelled
+// when the Device is closed. It returns an error if the Device has already
+// been closed.
+func (d *Device) backgroundContext(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return nil, nil, errors.New("device is closed")
+	}
+	if d.done == nil {
+		d.done = make(chan struct{})
+	}
+	done := d.done
+	d.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel, nil
+}
+
+func (d *Device) watchIntensities(ctx context.Context, interval time.Duration, ch chan<- []int) {
+	defer close(ch)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var last []int
+	for {
+		if intensities, err := d.GetIntensities(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			d.logf(ctx, "heliospectra: polling intensities on %s: %s", d.addr, err)
+		} else if last == nil || !IntensitiesApproxEqual(last, intensities, 0) {
+			last = intensities
+			select {
+			case ch <- intensities:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package heliospectra
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDevice_WatchIntensities(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var mu sync.Mutex
+	polls := 0
+	device, server := newTestDevice(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		polls++
+		n := polls
+		mu.Unlock()
+
+		body := statusResponse
+		if n > 3 {
+			body = strings.Replace(body, "<j>0:0,1:0,2:0,3:0,</j>", "<j>0:100,1:0,2:0,3:0,</j>", 1)
+		}
+		w.Write([]byte(body))
+	})
+	defer server.Close()
+
+	watchCtx, stop := context.WithCancel(ctx)
+	ch, err := device.WatchIntensities(watchCtx, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range [][]int{{0, 0, 0, 0}, {100, 0, 0, 0}} {
+		select {
+		case got := <-ch:
+			if !reflect.DeepEqual(expected, got) {
+				t.Errorf("expected intensities %v, got %v", expected, got)
+			}
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for intensities %v", expected)
+		}
+	}
+
+	select {
+	case got := <-ch:
+		t.Errorf("expected no more values while intensities are unchanged, got %v", got)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	stop()
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Errorf("expected channel to be closed after ctx is done")
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for channel to close")
+	}
+
+	if _, err := device.WatchIntensities(ctx, 0); err == nil {
+		t.Errorf("expected an error for a zero interval, got none")
+	}
+}
+
+func TestDevice_Close(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	device, server := newTestDevice(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(statusResponse))
+	})
+	defer server.Close()
+
+	ch, err := device.WatchIntensities(ctx, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-ch // initial intensities
+
+	device.Close()
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Errorf("expected channel to be closed after Close")
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for the watcher to exit after Close")
+	}
+
+	device.Close() // must not panic
+	if _, err := device.WatchIntensities(ctx, 10*time.Millisecond); err == nil {
+		t.Errorf("expected an error watching a closed device, got none")
+	}
+	if _, err := device.Status(ctx); err != nil {
+		t.Errorf("expected requests to still work after Close, got %s", err)
+	}
+}